@@ -0,0 +1,379 @@
+// Self-describing proof bundle.
+//
+// A bundle carries everything a verifier needs to know which statement a
+// proof is for: the policy, the ordered public inputs, the fingerprint of the
+// VerifyingKey it verifies under and when it was made. It has a compact
+// binary encoding (the default of bundle.Save and of `zkid prove`) and
+// a JSON encoding for APIs. Binary layout, big-endian:
+//
+//	magic          6 bytes  "ZKIDPB"
+//	format         uint16   FormatVersion
+//	policy_id      uint64
+//	version        uint64
+//	created_at     int64    Unix seconds, UTC
+//	vk_fingerprint 32 bytes SHA-256 of the serialized VerifyingKey
+//	n_public       uint16
+//	public_inputs  n_public × 32 bytes, circuit order
+//	proof_len      uint32
+//	proof          proof_len bytes, gnark compressed Groth16 BN254 proof
+package bundle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+)
+
+const (
+	// Magic starts every binary bundle.
+	Magic = "ZKIDPB"
+	// FormatVersion is the bundle format written by this package.
+	FormatVersion = 1
+	// JSONFormat is the "format" member of a JSON bundle.
+	JSONFormat = "zkid-proof-bundle/v1"
+
+	maxPublicInputs = 1 << 10
+	maxProofLen     = 1 << 16
+)
+
+// ErrFormat is returned for data that is not a well-formed bundle.
+var ErrFormat = errors.New("malformed proof bundle")
+
+// Format selects an encoding in Save.
+type Format int
+
+const (
+	Binary Format = iota
+	JSON
+)
+
+// Fingerprint is the SHA-256 of a VerifyingKey as written by its WriteTo,
+// i.e. the hash recorded for verifying.key in the artifact manifest.
+type Fingerprint [32]byte
+
+func (f Fingerprint) String() string {
+	return hex.EncodeToString(f[:])
+}
+
+// FingerprintOf computes the fingerprint of vk.
+func FingerprintOf(vk groth16.VerifyingKey) (Fingerprint, error) {
+	h := sha256.New()
+	if _, err := vk.WriteTo(h); err != nil {
+		return Fingerprint{}, fmt.Errorf("failed to serialize verifying key: %w", err)
+	}
+	var f Fingerprint
+	copy(f[:], h.Sum(nil))
+	return f, nil
+}
+
+// Bundle is a Groth16 proof with the statement it proves.
+type Bundle struct {
+	PolicyID      int64
+	Version       int64
+	PublicInputs  []*big.Int // circuit order, PolicyID and Version included
+	VKFingerprint Fingerprint
+	CreatedAt     time.Time
+	Proof         groth16.Proof
+}
+
+// New bundles proof with its public inputs, fingerprinting vk and stamping
+// the current time.
+func New(policyID, version int64, public []*big.Int, vk groth16.VerifyingKey, proof groth16.Proof) (*Bundle, error) {
+	fp, err := FingerprintOf(vk)
+	if err != nil {
+		return nil, err
+	}
+	b := &Bundle{
+		PolicyID:      policyID,
+		Version:       version,
+		PublicInputs:  public,
+		VKFingerprint: fp,
+		CreatedAt:     time.Now().UTC().Truncate(time.Second),
+		Proof:         proof,
+	}
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// check validates the fields that the encodings cannot represent otherwise.
+func (b *Bundle) check() error {
+	if b.PolicyID < 0 || b.Version < 0 {
+		return fmt.Errorf("%w: negative policy id or version", ErrFormat)
+	}
+	if len(b.PublicInputs) > maxPublicInputs {
+		return fmt.Errorf("%w: %d public inputs", ErrFormat, len(b.PublicInputs))
+	}
+	for i, x := range b.PublicInputs {
+		if x == nil || x.Sign() < 0 || x.Cmp(fr.Modulus()) >= 0 {
+			return fmt.Errorf("%w: public input %d is not in [0, r)", ErrFormat, i)
+		}
+	}
+	if b.Proof == nil {
+		return fmt.Errorf("%w: missing proof", ErrFormat)
+	}
+	return nil
+}
+
+func (b *Bundle) proofBytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := b.Proof.WriteTo(&buf); err != nil {
+		return nil, fmt.Errorf("failed to serialize proof: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func readProof(data []byte) (groth16.Proof, error) {
+	proof := groth16.NewProof(ecc.BN254)
+	n, err := proof.ReadFrom(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: proof: %v", ErrFormat, err)
+	}
+	if n != int64(len(data)) {
+		return nil, fmt.Errorf("%w: %d trailing proof bytes", ErrFormat, int64(len(data))-n)
+	}
+	return proof, nil
+}
+
+// MarshalBinary encodes the bundle in the binary layout above.
+func (b *Bundle) MarshalBinary() ([]byte, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	proof, err := b.proofBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(Magic)
+	binary.Write(&buf, binary.BigEndian, uint16(FormatVersion))
+	binary.Write(&buf, binary.BigEndian, uint64(b.PolicyID))
+	binary.Write(&buf, binary.BigEndian, uint64(b.Version))
+	binary.Write(&buf, binary.BigEndian, b.CreatedAt.Unix())
+	buf.Write(b.VKFingerprint[:])
+	binary.Write(&buf, binary.BigEndian, uint16(len(b.PublicInputs)))
+	for _, x := range b.PublicInputs {
+		buf.Write(x.FillBytes(make([]byte, fr.Bytes)))
+	}
+	binary.Write(&buf, binary.BigEndian, uint32(len(proof)))
+	buf.Write(proof)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a binary bundle. Trailing bytes are rejected.
+func (b *Bundle) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	magic := make([]byte, len(Magic))
+	if _, err := io.ReadFull(r, magic); err != nil || string(magic) != Magic {
+		return fmt.Errorf("%w: bad magic", ErrFormat)
+	}
+	var header struct {
+		Format    uint16
+		PolicyID  uint64
+		Version   uint64
+		CreatedAt int64
+		VK        Fingerprint
+		NPublic   uint16
+	}
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return fmt.Errorf("%w: header: %v", ErrFormat, err)
+	}
+	if header.Format != FormatVersion {
+		return fmt.Errorf("%w: unsupported format version %d", ErrFormat, header.Format)
+	}
+	if header.PolicyID > 1<<63-1 || header.Version > 1<<63-1 {
+		return fmt.Errorf("%w: policy id or version out of range", ErrFormat)
+	}
+	if int(header.NPublic) > maxPublicInputs {
+		return fmt.Errorf("%w: %d public inputs", ErrFormat, header.NPublic)
+	}
+
+	public := make([]*big.Int, header.NPublic)
+	elem := make([]byte, fr.Bytes)
+	for i := range public {
+		if _, err := io.ReadFull(r, elem); err != nil {
+			return fmt.Errorf("%w: public input %d: %v", ErrFormat, i, err)
+		}
+		public[i] = new(big.Int).SetBytes(elem)
+	}
+
+	var proofLen uint32
+	if err := binary.Read(r, binary.BigEndian, &proofLen); err != nil {
+		return fmt.Errorf("%w: proof length: %v", ErrFormat, err)
+	}
+	if proofLen > maxProofLen || int(proofLen) != r.Len() {
+		return fmt.Errorf("%w: proof length %d, %d bytes left", ErrFormat, proofLen, r.Len())
+	}
+	raw := make([]byte, proofLen)
+	io.ReadFull(r, raw)
+	proof, err := readProof(raw)
+	if err != nil {
+		return err
+	}
+
+	out := Bundle{
+		PolicyID:      int64(header.PolicyID),
+		Version:       int64(header.Version),
+		PublicInputs:  public,
+		VKFingerprint: header.VK,
+		CreatedAt:     time.Unix(header.CreatedAt, 0).UTC(),
+		Proof:         proof,
+	}
+	if err := out.check(); err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
+
+// bundleJSON is the JSON form of a bundle. Public inputs are decimal strings.
+type bundleJSON struct {
+	Format        string    `json:"format"`
+	PolicyID      int64     `json:"policy_id"`
+	Version       int64     `json:"version"`
+	PublicInputs  []string  `json:"public_inputs"`
+	VKFingerprint string    `json:"vk_fingerprint"`
+	CreatedAt     time.Time `json:"created_at"`
+	Proof         string    `json:"proof"` // base64 of the binary proof bytes
+}
+
+// MarshalJSON encodes the bundle as JSON.
+func (b *Bundle) MarshalJSON() ([]byte, error) {
+	if err := b.check(); err != nil {
+		return nil, err
+	}
+	proof, err := b.proofBytes()
+	if err != nil {
+		return nil, err
+	}
+	public := make([]string, len(b.PublicInputs))
+	for i, x := range b.PublicInputs {
+		public[i] = x.String()
+	}
+	return json.Marshal(bundleJSON{
+		Format:        JSONFormat,
+		PolicyID:      b.PolicyID,
+		Version:       b.Version,
+		PublicInputs:  public,
+		VKFingerprint: b.VKFingerprint.String(),
+		CreatedAt:     b.CreatedAt,
+		Proof:         base64.StdEncoding.EncodeToString(proof),
+	})
+}
+
+// UnmarshalJSON decodes a JSON bundle. Unknown members are rejected.
+func (b *Bundle) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var raw bundleJSON
+	if err := dec.Decode(&raw); err != nil {
+		return fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if raw.Format != JSONFormat {
+		return fmt.Errorf("%w: format %q, expected %q", ErrFormat, raw.Format, JSONFormat)
+	}
+
+	public := make([]*big.Int, len(raw.PublicInputs))
+	for i, s := range raw.PublicInputs {
+		x, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return fmt.Errorf("%w: public input %d: invalid integer %q", ErrFormat, i, s)
+		}
+		public[i] = x
+	}
+	var fp Fingerprint
+	if n, err := hex.Decode(fp[:], []byte(raw.VKFingerprint)); err != nil || n != len(fp) || len(raw.VKFingerprint) != 2*len(fp) {
+		return fmt.Errorf("%w: vk_fingerprint must be %d hex bytes", ErrFormat, len(fp))
+	}
+	rawProof, err := base64.StdEncoding.DecodeString(raw.Proof)
+	if err != nil {
+		return fmt.Errorf("%w: proof: %v", ErrFormat, err)
+	}
+	proof, err := readProof(rawProof)
+	if err != nil {
+		return err
+	}
+
+	out := Bundle{
+		PolicyID:      raw.PolicyID,
+		Version:       raw.Version,
+		PublicInputs:  public,
+		VKFingerprint: fp,
+		CreatedAt:     raw.CreatedAt.UTC(),
+		Proof:         proof,
+	}
+	if err := out.check(); err != nil {
+		return err
+	}
+	*b = out
+	return nil
+}
+
+// Decode reads a bundle in either encoding, telling them apart by the magic.
+// Input that is neither is reported as ErrFormat.
+func Decode(data []byte) (*Bundle, error) {
+	var b Bundle
+	var err error
+	if bytes.HasPrefix(data, []byte(Magic)) {
+		err = b.UnmarshalBinary(data)
+	} else if err = json.Unmarshal(data, &b); err != nil && !errors.Is(err, ErrFormat) {
+		// syntax errors are reported before UnmarshalJSON runs
+		err = fmt.Errorf("%w: %v", ErrFormat, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// Load reads a bundle file in either encoding.
+func Load(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	b, err := Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// Save writes the bundle to path in the given encoding.
+func Save(path string, b *Bundle, format Format) error {
+	var (
+		data []byte
+		err  error
+	)
+	switch format {
+	case Binary:
+		data, err = b.MarshalBinary()
+	case JSON:
+		data, err = json.MarshalIndent(b, "", "  ")
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unknown bundle format %d", format)
+	}
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package bundle_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/kanthub/zkid-zkp/bundle"
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/internal/testcircuit"
+	verify_age "github.com/kanthub/zkid-zkp/verifier_mock"
+)
+
+// newBundle bundles the proof of a fresh testcircuit fixture under policy 0,
+// which is not registered, and returns it with its verifying key.
+func newBundle(t *testing.T) (*bundle.Bundle, groth16.VerifyingKey) {
+	t.Helper()
+	f := testcircuit.New(t)
+	b, err := bundle.New(0, 1, f.Public, f.VK, f.Proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, f.VK
+}
+
+func proofBytes(t *testing.T, proof groth16.Proof) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if _, err := proof.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func assertEqual(t *testing.T, got, want *bundle.Bundle) {
+	t.Helper()
+	if got.PolicyID != want.PolicyID || got.Version != want.Version {
+		t.Fatalf("policy %d v%d, want %d v%d", got.PolicyID, got.Version, want.PolicyID, want.Version)
+	}
+	if len(got.PublicInputs) != len(want.PublicInputs) {
+		t.Fatalf("%d public inputs, want %d", len(got.PublicInputs), len(want.PublicInputs))
+	}
+	for i := range got.PublicInputs {
+		if got.PublicInputs[i].Cmp(want.PublicInputs[i]) != 0 {
+			t.Fatalf("public input %d: %s, want %s", i, got.PublicInputs[i], want.PublicInputs[i])
+		}
+	}
+	if got.VKFingerprint != want.VKFingerprint {
+		t.Fatalf("fingerprint %s, want %s", got.VKFingerprint, want.VKFingerprint)
+	}
+	if !got.CreatedAt.Equal(want.CreatedAt) {
+		t.Fatalf("created at %s, want %s", got.CreatedAt, want.CreatedAt)
+	}
+	if !bytes.Equal(proofBytes(t, got.Proof), proofBytes(t, want.Proof)) {
+		t.Fatal("proof differs")
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	b, vk := newBundle(t)
+
+	bin, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBin bundle.Bundle
+	if err := fromBin.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, &fromBin, b)
+	if again, err := fromBin.MarshalBinary(); err != nil || !bytes.Equal(again, bin) {
+		t.Fatalf("binary encoding is not stable: %v", err)
+	}
+
+	js, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON bundle.Bundle
+	if err := json.Unmarshal(js, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	assertEqual(t, &fromJSON, b)
+
+	dir := t.TempDir()
+	for name, format := range map[string]bundle.Format{"proof.bin": bundle.Binary, "proof.json": bundle.JSON} {
+		path := filepath.Join(dir, name)
+		if err := bundle.Save(path, b, format); err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := bundle.Load(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertEqual(t, loaded, b)
+	}
+
+	// the decoded proof still verifies
+	if err := testcircuit.Verify(vk, fromBin.Proof, fromBin.PublicInputs); err != nil {
+		t.Fatalf("decoded proof: %v", err)
+	}
+}
+
+// TestFingerprintMismatch checks that a bundle is rejected under a key other
+// than the one it names, before its policy or proof is looked at.
+func TestFingerprintMismatch(t *testing.T) {
+	b, vk := newBundle(t)
+	_, otherVK := newBundle(t)
+	bounds := verify_age.Bounds{Threshold: big.NewInt(18)}
+
+	// with its own key the bundle gets past the fingerprint check and
+	// fails on its unregistered policy
+	if err := verify_age.VerifyProof(b, bounds, vk); !errors.Is(err, circuits.ErrUnknownPolicy) {
+		t.Fatalf("own key: got %v, want ErrUnknownPolicy", err)
+	}
+	if err := verify_age.VerifyProof(b, bounds, otherVK); !errors.Is(err, verify_age.ErrBadProof) {
+		t.Fatalf("other key: got %v, want ErrBadProof", err)
+	}
+	b.VKFingerprint[0] ^= 1
+	if err := verify_age.VerifyProof(b, bounds, vk); !errors.Is(err, verify_age.ErrBadProof) {
+		t.Fatalf("tampered fingerprint: got %v, want ErrBadProof", err)
+	}
+}
+
+func TestTruncated(t *testing.T) {
+	b, _ := newBundle(t)
+	bin, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for n := 0; n < len(bin); n++ {
+		var out bundle.Bundle
+		if err := out.UnmarshalBinary(bin[:n]); !errors.Is(err, bundle.ErrFormat) {
+			t.Fatalf("binary cut at %d of %d bytes: got %v, want ErrFormat", n, len(bin), err)
+		}
+	}
+	for n := 0; n < len(js); n++ {
+		if _, err := bundle.Decode(js[:n]); !errors.Is(err, bundle.ErrFormat) {
+			t.Fatalf("JSON cut at %d of %d bytes: got %v, want ErrFormat", n, len(js), err)
+		}
+	}
+	var out bundle.Bundle
+	if err := out.UnmarshalBinary(append(bin, 0)); !errors.Is(err, bundle.ErrFormat) {
+		t.Fatalf("trailing byte: got %v, want ErrFormat", err)
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	b, _ := newBundle(t)
+	bin, err := b.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := json.Marshal(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, version := range []uint16{0, bundle.FormatVersion + 1, 0xffff} {
+		data := bytes.Clone(bin)
+		binary.BigEndian.PutUint16(data[len(bundle.Magic):], version)
+		if _, err := bundle.Decode(data); !errors.Is(err, bundle.ErrFormat) {
+			t.Fatalf("binary format %d: got %v, want ErrFormat", version, err)
+		}
+	}
+	for _, data := range [][]byte{
+		[]byte(strings.Replace(string(js), bundle.JSONFormat, "zkid-proof-bundle/v2", 1)),
+		[]byte(strings.Replace(string(js), `"format"`, `"format_version":2,"format"`, 1)),
+		[]byte("ZKIDPX" + string(bin[len(bundle.Magic):])),
+	} {
+		if _, err := bundle.Decode(data); !errors.Is(err, bundle.ErrFormat) {
+			t.Fatalf("%.40q: got %v, want ErrFormat", data, err)
+		}
+	}
+}
@@ -5,6 +5,7 @@ package circuits
 import (
 	"github.com/consensys/gnark/frontend"
 	mimc "github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
 )
 
 // More general circuit definition, allowing zkID to support multiple attribute validations
@@ -15,6 +16,13 @@ type Circuit struct {
 	Version   frontend.Variable `gnark:",public"`
 	C         frontend.Variable `gnark:",public"` // Commitment of the attribute
 	Threshold frontend.Variable `gnark:",public"`
+	// Second bound, only used by range predicates (0 otherwise)
+	UpperBound frontend.Variable `gnark:",public"`
+	// Merkle root of the trusted issuer set (see VerifyIssuer)
+	IssuersRoot frontend.Variable `gnark:",public"`
+	// Verifier-chosen scope and the holder's nullifier in it (see DeriveNullifier)
+	Scope     frontend.Variable `gnark:",public"`
+	Nullifier frontend.Variable `gnark:",public"`
 
 	// Private inputs (order is flexible)
 	Name       frontend.Variable // User name
@@ -23,7 +31,37 @@ type Circuit struct {
 	Address    frontend.Variable // Address
 	IdentityID frontend.Variable // Identity number
 	AttrValue  frontend.Variable // Attribute value (e.g., face/fingerprint biometric)
-	DID        frontend.Variable
+	DID        frontend.Variable // MiMC of the attributes above, enforced in Define
+	DIDVersion frontend.Variable // DIDVersion1 (attribute-derived) or DIDVersion2 (secret-seeded)
+	Secret     frontend.Variable // Holder secret for DIDVersion2, 0 for DIDVersion1
+	Salt       frontend.Variable // Random blinding factor r, makes C hiding
+
+	// Issuer: key, its position in the trusted set and the signature on C
+	IssuerKey   eddsa.PublicKey
+	IssuerIndex frontend.Variable
+	IssuerPath  [IssuerTreeDepth]frontend.Variable
+	Signature   eddsa.Signature
+
+	// Compile-time configuration, not part of the witness
+	Policy Policy `gnark:"-"`
+}
+
+// Attribute returns the private variable selected by a predicate.
+func (c *Circuit) Attribute(a Attribute) frontend.Variable {
+	switch a {
+	case AttrName:
+		return c.Name
+	case AttrNation:
+		return c.Nation
+	case AttrAddress:
+		return c.Address
+	case AttrIdentityID:
+		return c.IdentityID
+	case AttrValue:
+		return c.AttrValue
+	default:
+		return c.Age
+	}
 }
 
 // Define defines the circuit constraints
@@ -33,8 +71,26 @@ type Circuit struct {
 // proven in zero-knowledge.
 
 func (c *Circuit) Define(api frontend.API) error {
+	if err := c.Policy.Predicate.Validate(); err != nil {
+		return err
+	}
+
+	// The compiled circuit only serves the policy it was built for
+	api.AssertIsEqual(c.PolicyID, c.Policy.ID)
+	api.AssertIsEqual(c.Version, c.Policy.Version)
+
 	// -------------------------------------------------
-	// 1. Poseidon hash: h = Poseidon(policy_id, version, did, m, r)
+	// 0. DID must be derived from the committed attributes
+	// -------------------------------------------------
+	did, err := c.DeriveDID(api)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(did, c.DID)
+
+	// -------------------------------------------------
+	// 1. Commitment: C = MiMC(policy_id, version, m, did, r)
+	//    (a candidate for Poseidon later; r is the private Salt)
 	// -------------------------------------------------
 	// hasher, err := poseidon2.NewMerkleDamgardHasher(api)
 	hasher, err := mimc.NewMiMC(api)
@@ -53,23 +109,39 @@ func (c *Circuit) Define(api frontend.API) error {
 		c.IdentityID,
 		c.AttrValue,
 		c.DID,
+		c.Salt,
 	)
 
 	// Compute the hash output (as a field element)
 	h := hasher.Sum()
 
-	api.Println("Poseidon hash result:", h)
-
 	api.AssertIsEqual(h, c.C) // Assert the hash result matches the public commitment
 
+	// -------------------------------------------------
+	// 1b. C must be signed by a trusted issuer (no self-attested attributes)
+	// -------------------------------------------------
+	if err := c.VerifyIssuer(api); err != nil {
+		return err
+	}
+
 	// -------------------------------------------------
 	// 2. HashToCurve placeholder logic (for future use)
 	// -------------------------------------------------
 
 	// -------------------------------------------------
-	// 3. Age ≥ threshold constraint
+	// 3. Policy predicate, e.g. Age ≥ Threshold
 	// -------------------------------------------------
-	api.AssertIsLessOrEqual(c.Threshold, c.AttrValue)
+	p := c.Policy.Predicate
+	p.Assert(api, c.Attribute(p.Attribute), c.Threshold, c.UpperBound)
+
+	// -------------------------------------------------
+	// 4. Scoped nullifier (0 when Scope is 0)
+	// -------------------------------------------------
+	nullifier, err := c.DeriveNullifier(api)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(nullifier, c.Nullifier)
 
 	return nil
 }
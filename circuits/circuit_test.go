@@ -0,0 +1,175 @@
+package circuits_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+	"github.com/consensys/gnark/test"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/issuer"
+	"github.com/kanthub/zkid-zkp/merkle"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+)
+
+// fixture is a DIDv2 credential signed by the second issuer of a two-issuer
+// set, with its commitment appended between others to a commitment tree.
+type fixture struct {
+	policy      circuits.Policy
+	key         *issuer.PrivateKey // signed cred
+	outsider    *issuer.PrivateKey // not in issuers
+	issuers     *issuer.Set
+	secret      *big.Int
+	cred        *proof_age.Credential
+	commitments *merkle.Tree
+}
+
+func newFixture(t *testing.T, policyID int64) *fixture {
+	t.Helper()
+	policy, err := circuits.LookupPolicy(policyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &fixture{policy: policy}
+	other := generateKey(t)
+	f.key = generateKey(t)
+	f.outsider = generateKey(t)
+	if f.issuers, err = issuer.NewSet(&other.PublicKey, &f.key.PublicKey); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.secret, err = proof_age.GenerateSecret(); err != nil {
+		t.Fatal(err)
+	}
+	salt, err := proof_age.GenerateSalt()
+	if err != nil {
+		t.Fatal(err)
+	}
+	attr := []byte{0xf1, 0x9e, 0x42}
+	did, err := proof_age.ComputeLocalDID(proof_age.DIDv2, "Alice", "FR", "1 rue de la Paix", 30, 4242, attr, f.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.cred, err = proof_age.NewCredential(policy, "Alice", "FR", "1 rue de la Paix", 30, 4242, attr, f.secret, did, salt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.cred.Sign(f.key); err != nil {
+		t.Fatal(err)
+	}
+
+	if f.commitments, err = proof_age.NewCommitmentTree(); err != nil {
+		t.Fatal(err)
+	}
+	for _, C := range []*big.Int{big.NewInt(11), f.cred.C, big.NewInt(13)} {
+		if _, err := f.commitments.Append(C); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return f
+}
+
+func generateKey(t *testing.T) *issuer.PrivateKey {
+	t.Helper()
+	key, err := issuer.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+// assignment returns a fresh witness of the credential for opts, with the
+// fixture's issuer set and secret filled in.
+func (f *fixture) assignment(t *testing.T, opts proof_age.ProofOptions) frontend.Circuit {
+	t.Helper()
+	opts.Issuers = f.issuers
+	opts.Secret = f.secret
+	if opts.Threshold == nil {
+		opts.Threshold = big.NewInt(18)
+	}
+	c, err := f.cred.Assignment(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := proof_age.PolicyAssignment(f.policy, c, opts.Commitments, opts.Message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+// isSolved reports whether w satisfies the circuit of the fixture's policy.
+func (f *fixture) isSolved(w frontend.Circuit) error {
+	return test.IsSolved(circuits.Placeholder(f.policy), w, ecc.BN254.ScalarField())
+}
+
+// setSignature assigns key and its signature on msg to pub and sig.
+func setSignature(t *testing.T, pub *eddsa.PublicKey, sig *eddsa.Signature, key *issuer.PrivateKey, msg *big.Int) {
+	t.Helper()
+	s, err := issuer.Sign(key, msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rx, ry, sigS, err := issuer.SignatureParts(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub.A.X, pub.A.Y = issuer.Point(&key.PublicKey)
+	sig.R.X, sig.R.Y, sig.S = rx, ry, sigS
+}
+
+// TestCircuitIssuer checks that only a commitment of the proven attributes,
+// signed by an issuer of the trusted set, satisfies the circuit.
+func TestCircuitIssuer(t *testing.T) {
+	f := newFixture(t, 1)
+
+	cases := []struct {
+		name   string
+		mutate func(c *circuits.Circuit)
+		solved bool
+	}{
+		{"valid", func(c *circuits.Circuit) {}, true},
+		{"issuer outside the set", func(c *circuits.Circuit) {
+			setSignature(t, &c.IssuerKey, &c.Signature, f.outsider, f.cred.C)
+		}, false},
+		{"signature by another key", func(c *circuits.Circuit) {
+			key := f.key.PublicKey
+			setSignature(t, &c.IssuerKey, &c.Signature, f.outsider, f.cred.C)
+			c.IssuerKey.A.X, c.IssuerKey.A.Y = issuer.Point(&key)
+		}, false},
+		{"signature on another commitment", func(c *circuits.Circuit) {
+			setSignature(t, &c.IssuerKey, &c.Signature, f.key, big.NewInt(1))
+		}, false},
+		{"wrong issuer set root", func(c *circuits.Circuit) {
+			c.IssuersRoot = big.NewInt(1)
+		}, false},
+		{"tampered age", func(c *circuits.Circuit) {
+			c.Age = big.NewInt(31)
+		}, false},
+		{"tampered nation", func(c *circuits.Circuit) {
+			c.Nation = big.NewInt(1)
+		}, false},
+		{"tampered salt", func(c *circuits.Circuit) {
+			c.Salt = big.NewInt(1)
+		}, false},
+		{"tampered commitment", func(c *circuits.Circuit) {
+			c.C = new(big.Int).Add(f.cred.C, big.NewInt(1))
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := f.assignment(t, proof_age.ProofOptions{}).(*circuits.Circuit)
+			tc.mutate(c)
+			err := f.isSolved(c)
+			if tc.solved && err != nil {
+				t.Fatalf("valid witness rejected: %v", err)
+			}
+			if !tc.solved && err == nil {
+				t.Fatal("invalid witness accepted")
+			}
+		})
+	}
+}
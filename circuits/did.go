@@ -0,0 +1,72 @@
+// DID binding: the DID is re-derived from the private attributes inside the
+// circuit, so a proof shows that the committed DID belongs to those attributes.
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	mimc "github.com/consensys/gnark/std/hash/mimc"
+
+	"github.com/kanthub/zkid-zkp/encoding"
+)
+
+const (
+	// DIDVersion1 identifies the domain-separated, attribute-derived DID.
+	DIDVersion1 = 1
+	// DIDVersion2 identifies the DID seeded by a holder secret.
+	DIDVersion2 = 2
+)
+
+// DIDDomain is absorbed first so that a DID can never equal another MiMC
+// output of this system (e.g. a commitment) over the same elements.
+var DIDDomain = encoding.DomainTag("did")
+
+// DeriveDID computes, depending on the private DIDVersion,
+//
+//	v1: DID = MiMC(DIDDomain, 1, Name, Nation, Address, Age, IdentityID, AttrValue)
+//	v2: DID = MiMC(DIDDomain, 2, Secret, Name, Nation, Address, Age, IdentityID, AttrValue)
+//
+// Every attribute is one canonical field element (see package encoding), so
+// the absorbed sequence has a fixed length and the mapping is injective.
+// A v2 DID cannot be recomputed from leaked attributes alone, and a valid proof
+// shows knowledge of Secret. For v1 the Secret must be 0.
+// MiMC is used instead of Keccak because it costs a few hundred constraints per
+// element. The out-of-circuit counterpart is proof_age.ComputeLocalDID.
+func (c *Circuit) DeriveDID(api frontend.API) (frontend.Variable, error) {
+	// isV2 ∈ {0, 1} ⇔ DIDVersion ∈ {1, 2}
+	isV2 := api.Sub(c.DIDVersion, DIDVersion1)
+	api.AssertIsBoolean(isV2)
+	api.AssertIsEqual(api.Mul(api.Sub(1, isV2), c.Secret), 0)
+
+	v1, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	v1.Write(
+		DIDDomain,
+		DIDVersion1,
+		c.Name,
+		c.Nation,
+		c.Address,
+		c.Age,
+		c.IdentityID,
+		c.AttrValue,
+	)
+
+	v2, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	v2.Write(
+		DIDDomain,
+		DIDVersion2,
+		c.Secret,
+		c.Name,
+		c.Nation,
+		c.Address,
+		c.Age,
+		c.IdentityID,
+		c.AttrValue,
+	)
+
+	return api.Select(isV2, v2.Sum(), v1.Sum()), nil
+}
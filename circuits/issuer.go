@@ -0,0 +1,58 @@
+// Issuer binding: the commitment C must carry an EdDSA signature of an
+// issuer from the trusted set, so only oracle-vetted attributes can be
+// proven. The issuer itself stays hidden: its key is private and only the
+// Merkle root of the trusted set is public.
+package circuits
+
+import (
+	tedwards "github.com/consensys/gnark-crypto/ecc/twistededwards"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/algebra/native/twistededwards"
+	mimc "github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+
+	"github.com/kanthub/zkid-zkp/encoding"
+)
+
+// IssuerTreeDepth is the depth of the trusted issuer tree, which holds up to
+// 2^IssuerTreeDepth issuers. Changing it changes the circuit.
+const IssuerTreeDepth = 8
+
+// IssuerDomain is absorbed first into an issuer leaf, so that a leaf is never
+// an inner node or another MiMC output of this system.
+var IssuerDomain = encoding.DomainTag("issuer")
+
+// VerifyIssuer asserts that Signature is a valid EdDSA signature of C under
+// the private IssuerKey, on the twisted Edwards curve embedded in BN254 with
+// MiMC as hash, and that
+//
+//	leaf = MiMC(IssuerDomain, IssuerKey.X, IssuerKey.Y)
+//
+// sits at IssuerIndex of the tree with root IssuersRoot. The out-of-circuit
+// counterparts are issuer.Sign, issuer.Verify and issuer.Set. The signature
+// costs a few thousand constraints, the path one MiMC per level.
+func (c *Circuit) VerifyIssuer(api frontend.API) error {
+	curve, err := twistededwards.NewEdCurve(api, tedwards.BN254)
+	if err != nil {
+		return err
+	}
+	hasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	if err := eddsa.Verify(curve, c.Signature, c.C, c.IssuerKey, &hasher); err != nil {
+		return err
+	}
+
+	leafHasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return err
+	}
+	leafHasher.Write(IssuerDomain, c.IssuerKey.A.X, c.IssuerKey.A.Y)
+	root, err := MerkleRoot(api, leafHasher.Sum(), c.IssuerIndex, c.IssuerPath[:])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(root, c.IssuersRoot)
+	return nil
+}
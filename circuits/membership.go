@@ -0,0 +1,144 @@
+// Membership mode: the commitment C is private and proven to be a leaf of a
+// public Merkle root of all issued commitments, so presentations of one
+// credential cannot be linked by comparing C.
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/std/signature/eddsa"
+)
+
+// CommitmentTreeDepth is the depth of the tree of issued commitments, which
+// holds up to 2^CommitmentTreeDepth credentials. Changing it changes the
+// membership circuits.
+const CommitmentTreeDepth = 20
+
+// MembershipCircuit is Circuit with C moved from the public inputs to the
+// witness. In its place the public CommitmentsRoot is the root of the
+// commitment tree; all other constraints are those of Circuit.
+type MembershipCircuit struct {
+
+	// Public inputs, in the order of Circuit with C replaced by the root
+	PolicyID        frontend.Variable `gnark:",public"`
+	Version         frontend.Variable `gnark:",public"`
+	CommitmentsRoot frontend.Variable `gnark:",public"` // Merkle root of all issued C
+	Threshold       frontend.Variable `gnark:",public"`
+	UpperBound      frontend.Variable `gnark:",public"`
+	IssuersRoot     frontend.Variable `gnark:",public"`
+	Scope           frontend.Variable `gnark:",public"`
+	Nullifier       frontend.Variable `gnark:",public"`
+
+	// The commitment and its position in the tree
+	C               frontend.Variable
+	CommitmentIndex frontend.Variable
+	CommitmentPath  [CommitmentTreeDepth]frontend.Variable
+
+	// Private inputs of Circuit
+	Name       frontend.Variable
+	Age        frontend.Variable
+	Nation     frontend.Variable
+	Address    frontend.Variable
+	IdentityID frontend.Variable
+	AttrValue  frontend.Variable
+	DID        frontend.Variable
+	DIDVersion frontend.Variable
+	Secret     frontend.Variable
+	Salt       frontend.Variable
+
+	IssuerKey   eddsa.PublicKey
+	IssuerIndex frontend.Variable
+	IssuerPath  [IssuerTreeDepth]frontend.Variable
+	Signature   eddsa.Signature
+
+	// Compile-time configuration, not part of the witness
+	Policy Policy `gnark:"-"`
+}
+
+// NewMembershipCircuit wraps the assignment of Circuit c, whose C must sit
+// at index of the commitment tree with the given root and sibling path.
+func NewMembershipCircuit(c *Circuit, root, index frontend.Variable, path [CommitmentTreeDepth]frontend.Variable) *MembershipCircuit {
+	return &MembershipCircuit{
+		PolicyID:        c.PolicyID,
+		Version:         c.Version,
+		CommitmentsRoot: root,
+		Threshold:       c.Threshold,
+		UpperBound:      c.UpperBound,
+		IssuersRoot:     c.IssuersRoot,
+		Scope:           c.Scope,
+		Nullifier:       c.Nullifier,
+
+		C:               c.C,
+		CommitmentIndex: index,
+		CommitmentPath:  path,
+
+		Name:       c.Name,
+		Age:        c.Age,
+		Nation:     c.Nation,
+		Address:    c.Address,
+		IdentityID: c.IdentityID,
+		AttrValue:  c.AttrValue,
+		DID:        c.DID,
+		DIDVersion: c.DIDVersion,
+		Secret:     c.Secret,
+		Salt:       c.Salt,
+
+		IssuerKey:   c.IssuerKey,
+		IssuerIndex: c.IssuerIndex,
+		IssuerPath:  c.IssuerPath,
+		Signature:   c.Signature,
+
+		Policy: c.Policy,
+	}
+}
+
+// Circuit returns the view of m as a Circuit. Visibility only matters for
+// the witness layout, so Circuit.Define can constrain m's variables as is.
+func (m *MembershipCircuit) Circuit() *Circuit {
+	return &Circuit{
+		PolicyID:   m.PolicyID,
+		Version:    m.Version,
+		C:          m.C,
+		Threshold:  m.Threshold,
+		UpperBound: m.UpperBound,
+
+		IssuersRoot: m.IssuersRoot,
+		Scope:       m.Scope,
+		Nullifier:   m.Nullifier,
+
+		Name:       m.Name,
+		Age:        m.Age,
+		Nation:     m.Nation,
+		Address:    m.Address,
+		IdentityID: m.IdentityID,
+		AttrValue:  m.AttrValue,
+		DID:        m.DID,
+		DIDVersion: m.DIDVersion,
+		Secret:     m.Secret,
+		Salt:       m.Salt,
+
+		IssuerKey:   m.IssuerKey,
+		IssuerIndex: m.IssuerIndex,
+		IssuerPath:  m.IssuerPath,
+		Signature:   m.Signature,
+
+		Policy: m.Policy,
+	}
+}
+
+// Define enforces every constraint of Circuit and that C is the leaf at
+// CommitmentIndex of the tree with root CommitmentsRoot.
+func (m *MembershipCircuit) Define(api frontend.API) error {
+	if err := m.Circuit().Define(api); err != nil {
+		return err
+	}
+
+	// -------------------------------------------------
+	// 5. C must be one of the issued commitments
+	// -------------------------------------------------
+	root, err := MerkleRoot(api, m.C, m.CommitmentIndex, m.CommitmentPath[:])
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(root, m.CommitmentsRoot)
+	return nil
+}
@@ -0,0 +1,55 @@
+package circuits_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+)
+
+// TestMembershipCircuit checks that the commitment tree built with
+// merkle.Hash satisfies the in-circuit MiMC path, and that another root,
+// path or index does not.
+func TestMembershipCircuit(t *testing.T) {
+	f := newFixture(t, 11)
+
+	cases := []struct {
+		name   string
+		mutate func(m *circuits.MembershipCircuit)
+		solved bool
+	}{
+		{"valid", func(m *circuits.MembershipCircuit) {}, true},
+		{"wrong root", func(m *circuits.MembershipCircuit) {
+			m.CommitmentsRoot = new(big.Int).Add(f.commitments.Root(), big.NewInt(1))
+		}, false},
+		{"wrong sibling", func(m *circuits.MembershipCircuit) {
+			m.CommitmentPath[0] = big.NewInt(12)
+		}, false},
+		{"wrong upper sibling", func(m *circuits.MembershipCircuit) {
+			m.CommitmentPath[circuits.CommitmentTreeDepth-1] = big.NewInt(1)
+		}, false},
+		{"wrong index", func(m *circuits.MembershipCircuit) {
+			m.CommitmentIndex = big.NewInt(0)
+		}, false},
+		{"index beyond the tree", func(m *circuits.MembershipCircuit) {
+			m.CommitmentIndex = big.NewInt(1 + 1<<circuits.CommitmentTreeDepth)
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			m := f.assignment(t, proof_age.ProofOptions{Commitments: f.commitments}).(*circuits.MembershipCircuit)
+			if m.CommitmentsRoot.(*big.Int).Cmp(f.commitments.Root()) != 0 {
+				t.Fatal("witness is not against the tree root")
+			}
+			tc.mutate(m)
+			err := f.isSolved(m)
+			if tc.solved && err != nil {
+				t.Fatalf("valid witness rejected: %v", err)
+			}
+			if !tc.solved && err == nil {
+				t.Fatal("invalid witness accepted")
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+// Merkle membership: a private leaf is shown to belong to a public root
+// without revealing which leaf it is.
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	mimc "github.com/consensys/gnark/std/hash/mimc"
+)
+
+// MerkleRoot recomputes the root of a fixed-depth MiMC Merkle tree from a
+// leaf, its index and the siblings on its path (leaf level first). Bit i of
+// index selects whether the node at level i is a left (0) or right (1)
+// child; index is constrained to len(path) bits. Each level costs one
+// two-element MiMC. The out-of-circuit counterpart is package merkle.
+func MerkleRoot(api frontend.API, leaf, index frontend.Variable, path []frontend.Variable) (frontend.Variable, error) {
+	bits := api.ToBinary(index, len(path))
+	node := leaf
+	for i, sibling := range path {
+		hasher, err := mimc.NewMiMC(api)
+		if err != nil {
+			return nil, err
+		}
+		left := api.Select(bits[i], sibling, node)
+		right := api.Select(bits[i], node, sibling)
+		hasher.Write(left, right)
+		node = hasher.Sum()
+	}
+	return node, nil
+}
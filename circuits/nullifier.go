@@ -0,0 +1,35 @@
+// Scoped nullifiers: a public value that is the same for every proof of one
+// holder within a scope chosen by the verifier (an airdrop, a ballot), and
+// unrelated across scopes. A verifier that records the nullifiers it has
+// seen accepts one proof per holder and scope.
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	mimc "github.com/consensys/gnark/std/hash/mimc"
+
+	"github.com/kanthub/zkid-zkp/encoding"
+)
+
+// NullifierDomain is absorbed first into a nullifier, so that it is never a
+// DID, a commitment or a tree node over the same elements.
+var NullifierDomain = encoding.DomainTag("nullifier")
+
+// DeriveNullifier computes
+//
+//	Nullifier = MiMC(NullifierDomain, DID, Secret, Scope)   if Scope ≠ 0
+//	Nullifier = 0                                            if Scope = 0
+//
+// The DID makes it per holder, the Secret of a DIDv2 holder keeps the issuer
+// (who knows the attributes, hence a DIDv1 DID) from recomputing it. Scope 0
+// means "no nullifier": a shared default scope would give every verifier the
+// same value and link presentations across them. The out-of-circuit
+// counterpart is proof_age.ComputeNullifier.
+func (c *Circuit) DeriveNullifier(api frontend.API) (frontend.Variable, error) {
+	hasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	hasher.Write(NullifierDomain, c.DID, c.Secret, c.Scope)
+	return api.Select(api.IsZero(c.Scope), 0, hasher.Sum()), nil
+}
@@ -0,0 +1,136 @@
+// Policy registry: a PolicyID selects the predicate the circuit is compiled with.
+package circuits
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/constraint"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// ErrUnknownPolicy is returned for a PolicyID that is not registered.
+var ErrUnknownPolicy = errors.New("unknown policy")
+
+// Mode selects how a policy circuit exposes the commitment C.
+type Mode int
+
+const (
+	// ModeCommitment makes C a public input (Circuit). Presentations of one
+	// credential share C and can be linked.
+	ModeCommitment Mode = iota
+	// ModeMembership keeps C private and proves it is in the public tree of
+	// issued commitments (MembershipCircuit), so presentations are unlinkable.
+	ModeMembership
+	// ModeRateLimit is ModeMembership with the scoped nullifier replaced by
+	// one per epoch and message index below a public limit
+	// (RateLimitCircuit).
+	ModeRateLimit
+)
+
+func (m Mode) String() string {
+	switch m {
+	case ModeCommitment:
+		return "commitment"
+	case ModeMembership:
+		return "membership"
+	case ModeRateLimit:
+		return "rate-limit"
+	default:
+		return fmt.Sprintf("mode(%d)", int(m))
+	}
+}
+
+// Policy binds a (PolicyID, Version) pair to the predicate enforced by its circuit.
+type Policy struct {
+	ID        int64
+	Version   int64
+	Name      string
+	Predicate Predicate
+	Mode      Mode
+}
+
+// Built-in policies. Thresholds are public inputs chosen by the verifier,
+// e.g. 18 for an adult gate or 12 for the under-13 check. Policies 11-15 are
+// the membership-mode variants of 1-5, policies 21-25 the rate-limit ones.
+var policies = map[int64]Policy{
+	1: {ID: 1, Version: 1, Name: "age-at-least", Predicate: Predicate{Attribute: AttrAge, Operator: OpGreaterOrEqual}},
+	2: {ID: 2, Version: 1, Name: "age-at-most", Predicate: Predicate{Attribute: AttrAge, Operator: OpLessOrEqual}},
+	3: {ID: 3, Version: 1, Name: "age-in-range", Predicate: Predicate{Attribute: AttrAge, Operator: OpInRange}},
+	4: {ID: 4, Version: 1, Name: "nation-equals", Predicate: Predicate{Attribute: AttrNation, Operator: OpEqual}},
+	5: {ID: 5, Version: 1, Name: "nation-not-equals", Predicate: Predicate{Attribute: AttrNation, Operator: OpNotEqual}},
+
+	11: {ID: 11, Version: 1, Name: "age-at-least-unlinkable", Predicate: Predicate{Attribute: AttrAge, Operator: OpGreaterOrEqual}, Mode: ModeMembership},
+	12: {ID: 12, Version: 1, Name: "age-at-most-unlinkable", Predicate: Predicate{Attribute: AttrAge, Operator: OpLessOrEqual}, Mode: ModeMembership},
+	13: {ID: 13, Version: 1, Name: "age-in-range-unlinkable", Predicate: Predicate{Attribute: AttrAge, Operator: OpInRange}, Mode: ModeMembership},
+	14: {ID: 14, Version: 1, Name: "nation-equals-unlinkable", Predicate: Predicate{Attribute: AttrNation, Operator: OpEqual}, Mode: ModeMembership},
+	15: {ID: 15, Version: 1, Name: "nation-not-equals-unlinkable", Predicate: Predicate{Attribute: AttrNation, Operator: OpNotEqual}, Mode: ModeMembership},
+
+	21: {ID: 21, Version: 1, Name: "age-at-least-rate-limited", Predicate: Predicate{Attribute: AttrAge, Operator: OpGreaterOrEqual}, Mode: ModeRateLimit},
+	22: {ID: 22, Version: 1, Name: "age-at-most-rate-limited", Predicate: Predicate{Attribute: AttrAge, Operator: OpLessOrEqual}, Mode: ModeRateLimit},
+	23: {ID: 23, Version: 1, Name: "age-in-range-rate-limited", Predicate: Predicate{Attribute: AttrAge, Operator: OpInRange}, Mode: ModeRateLimit},
+	24: {ID: 24, Version: 1, Name: "nation-equals-rate-limited", Predicate: Predicate{Attribute: AttrNation, Operator: OpEqual}, Mode: ModeRateLimit},
+	25: {ID: 25, Version: 1, Name: "nation-not-equals-rate-limited", Predicate: Predicate{Attribute: AttrNation, Operator: OpNotEqual}, Mode: ModeRateLimit},
+}
+
+// LookupPolicy returns the registered policy for id.
+func LookupPolicy(id int64) (Policy, error) {
+	p, ok := policies[id]
+	if !ok {
+		return Policy{}, fmt.Errorf("policy id %d: %w", id, ErrUnknownPolicy)
+	}
+	return p, nil
+}
+
+// Policies returns all registered policies ordered by id.
+func Policies() []Policy {
+	out := make([]Policy, 0, len(policies))
+	for _, p := range policies {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// RegisterPolicy adds or replaces a policy in the registry.
+// It is meant to be called during program initialisation.
+func RegisterPolicy(p Policy) error {
+	if err := p.Predicate.Validate(); err != nil {
+		return fmt.Errorf("policy %d: %w", p.ID, err)
+	}
+	if p.Mode != ModeCommitment && p.Mode != ModeMembership && p.Mode != ModeRateLimit {
+		return fmt.Errorf("policy %d: unknown %s", p.ID, p.Mode)
+	}
+	policies[p.ID] = p
+	return nil
+}
+
+// NewCircuit returns the placeholder circuit of a commitment-mode policy.
+func NewCircuit(p Policy) *Circuit {
+	return &Circuit{Policy: p}
+}
+
+// Placeholder returns the placeholder circuit used to compile the given
+// policy: a Circuit, a MembershipCircuit or a RateLimitCircuit by mode.
+func Placeholder(p Policy) frontend.Circuit {
+	switch p.Mode {
+	case ModeMembership:
+		return &MembershipCircuit{Policy: p}
+	case ModeRateLimit:
+		return &RateLimitCircuit{Policy: p}
+	default:
+		return NewCircuit(p)
+	}
+}
+
+// Compile builds the R1CS of the policy circuit over the BN254 scalar field.
+func Compile(p Policy) (constraint.ConstraintSystem, error) {
+	cs, err := frontend.Compile(fr.Modulus(), r1cs.NewBuilder, Placeholder(p))
+	if err != nil {
+		return nil, fmt.Errorf("circuit compilation failed: %w", err)
+	}
+	return cs, nil
+}
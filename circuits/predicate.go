@@ -0,0 +1,136 @@
+// Predicate layer: each policy selects which private attribute is checked
+// and which comparison is applied against the public bounds.
+package circuits
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+)
+
+// Attribute identifies one of the private attributes of the circuit.
+type Attribute int
+
+const (
+	AttrAge Attribute = iota
+	AttrName
+	AttrNation
+	AttrAddress
+	AttrIdentityID
+	AttrValue
+)
+
+func (a Attribute) String() string {
+	switch a {
+	case AttrAge:
+		return "age"
+	case AttrName:
+		return "name"
+	case AttrNation:
+		return "nation"
+	case AttrAddress:
+		return "address"
+	case AttrIdentityID:
+		return "identity_id"
+	case AttrValue:
+		return "attr_value"
+	default:
+		return fmt.Sprintf("attribute(%d)", int(a))
+	}
+}
+
+// Operator is the comparison applied between the selected attribute and the
+// public inputs Threshold / UpperBound.
+type Operator int
+
+const (
+	OpGreaterOrEqual Operator = iota // attr ≥ Threshold
+	OpLessOrEqual                    // attr ≤ Threshold
+	OpEqual                          // attr == Threshold
+	OpNotEqual                       // attr != Threshold
+	OpInRange                        // Threshold ≤ attr ≤ UpperBound
+)
+
+func (o Operator) String() string {
+	switch o {
+	case OpGreaterOrEqual:
+		return ">="
+	case OpLessOrEqual:
+		return "<="
+	case OpEqual:
+		return "=="
+	case OpNotEqual:
+		return "!="
+	case OpInRange:
+		return "in-range"
+	default:
+		return fmt.Sprintf("operator(%d)", int(o))
+	}
+}
+
+// Predicate is a compile-time choice of (attribute, operator).
+// It is not part of the witness: every predicate yields its own constraint system.
+type Predicate struct {
+	Attribute Attribute
+	Operator  Operator
+}
+
+func (p Predicate) String() string {
+	return fmt.Sprintf("%s %s", p.Attribute, p.Operator)
+}
+
+// Validate reports whether the predicate refers to a known attribute and operator.
+func (p Predicate) Validate() error {
+	if p.Attribute < AttrAge || p.Attribute > AttrValue {
+		return fmt.Errorf("unknown attribute %d", int(p.Attribute))
+	}
+	if p.Operator < OpGreaterOrEqual || p.Operator > OpInRange {
+		return fmt.Errorf("unknown operator %d", int(p.Operator))
+	}
+	return nil
+}
+
+// Assert encodes the predicate as constraints over value and the public bounds.
+// UpperBound is only meaningful for OpInRange; other operators pin it to 0 so
+// that it cannot be altered freely in a valid proof.
+func (p Predicate) Assert(api frontend.API, value, threshold, upperBound frontend.Variable) {
+	switch p.Operator {
+	case OpGreaterOrEqual:
+		api.AssertIsLessOrEqual(threshold, value)
+	case OpLessOrEqual:
+		api.AssertIsLessOrEqual(value, threshold)
+	case OpEqual:
+		api.AssertIsEqual(value, threshold)
+	case OpNotEqual:
+		api.AssertIsDifferent(value, threshold)
+	case OpInRange:
+		api.AssertIsLessOrEqual(threshold, value)
+		api.AssertIsLessOrEqual(value, upperBound)
+	}
+	if p.Operator != OpInRange {
+		api.AssertIsEqual(upperBound, 0)
+	}
+}
+
+// Holds evaluates the predicate off-circuit on the same field encodings that are
+// assigned to the witness, so a prover can reject an unsatisfiable input early.
+func (p Predicate) Holds(value, threshold, upperBound *big.Int) bool {
+	if p.Operator != OpInRange && upperBound.Sign() != 0 {
+		return false
+	}
+	switch p.Operator {
+	case OpGreaterOrEqual:
+		return value.Cmp(threshold) >= 0
+	case OpLessOrEqual:
+		return value.Cmp(threshold) <= 0
+	case OpEqual:
+		return value.Cmp(threshold) == 0
+	case OpNotEqual:
+		return value.Cmp(threshold) != 0
+	case OpInRange:
+		return value.Cmp(threshold) >= 0 && value.Cmp(upperBound) <= 0
+	default:
+		return false
+	}
+}
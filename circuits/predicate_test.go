@@ -0,0 +1,92 @@
+package circuits
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/test"
+)
+
+// predicateCircuit isolates Predicate.Assert from the rest of Circuit.
+type predicateCircuit struct {
+	Value      frontend.Variable
+	Threshold  frontend.Variable `gnark:",public"`
+	UpperBound frontend.Variable `gnark:",public"`
+
+	Predicate Predicate `gnark:"-"`
+}
+
+func (c *predicateCircuit) Define(api frontend.API) error {
+	c.Predicate.Assert(api, c.Value, c.Threshold, c.UpperBound)
+	return nil
+}
+
+func TestPredicateAssert(t *testing.T) {
+	cases := []struct {
+		name                    string
+		op                      Operator
+		value, threshold, upper int64
+		holds                   bool
+	}{
+		{">= above", OpGreaterOrEqual, 19, 18, 0, true},
+		{">= at bound", OpGreaterOrEqual, 18, 18, 0, true},
+		{">= below", OpGreaterOrEqual, 17, 18, 0, false},
+		{">= upper bound set", OpGreaterOrEqual, 19, 18, 1, false},
+
+		{"<= below", OpLessOrEqual, 11, 12, 0, true},
+		{"<= at bound", OpLessOrEqual, 12, 12, 0, true},
+		{"<= above", OpLessOrEqual, 13, 12, 0, false},
+		{"<= upper bound set", OpLessOrEqual, 11, 12, 99, false},
+
+		{"== equal", OpEqual, 86, 86, 0, true},
+		{"== one less", OpEqual, 85, 86, 0, false},
+		{"== one more", OpEqual, 87, 86, 0, false},
+		{"== upper bound set", OpEqual, 86, 86, 86, false},
+
+		{"!= one less", OpNotEqual, 85, 86, 0, true},
+		{"!= one more", OpNotEqual, 87, 86, 0, true},
+		{"!= equal", OpNotEqual, 86, 86, 0, false},
+		{"!= upper bound set", OpNotEqual, 85, 86, 1, false},
+
+		{"range lower bound", OpInRange, 18, 18, 65, true},
+		{"range upper bound", OpInRange, 65, 18, 65, true},
+		{"range below", OpInRange, 17, 18, 65, false},
+		{"range above", OpInRange, 66, 18, 65, false},
+		{"range single value", OpInRange, 18, 18, 18, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := Predicate{Attribute: AttrAge, Operator: tc.op}
+			value, threshold, upper := big.NewInt(tc.value), big.NewInt(tc.threshold), big.NewInt(tc.upper)
+
+			err := test.IsSolved(&predicateCircuit{Predicate: p}, &predicateCircuit{
+				Value:      value,
+				Threshold:  threshold,
+				UpperBound: upper,
+			}, ecc.BN254.ScalarField())
+			if tc.holds && err != nil {
+				t.Fatalf("circuit rejects a satisfied predicate: %v", err)
+			}
+			if !tc.holds && err == nil {
+				t.Fatal("circuit accepts an unsatisfied predicate")
+			}
+			if got := p.Holds(value, threshold, upper); got != tc.holds {
+				t.Fatalf("Holds = %v, circuit says %v", got, tc.holds)
+			}
+		})
+	}
+}
+
+func TestPredicateValidate(t *testing.T) {
+	if err := (Predicate{Attribute: AttrNation, Operator: OpInRange}).Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := (Predicate{Attribute: AttrValue + 1}).Validate(); err == nil {
+		t.Fatal("unknown attribute accepted")
+	}
+	if err := (Predicate{Operator: OpInRange + 1}).Validate(); err == nil {
+		t.Fatal("unknown operator accepted")
+	}
+}
@@ -0,0 +1,189 @@
+// Rate-limit mode: instead of one nullifier per scope, the holder may spend
+// up to MessageLimit nullifiers per epoch, one for each message index below
+// the limit. A verifier that records the nullifiers of an epoch accepts at
+// most MessageLimit proofs per holder in it (see nullifier.RateLimiter).
+package circuits
+
+import (
+	"github.com/consensys/gnark/frontend"
+	mimc "github.com/consensys/gnark/std/hash/mimc"
+	"github.com/consensys/gnark/std/signature/eddsa"
+
+	"github.com/kanthub/zkid-zkp/encoding"
+)
+
+// MessageLimitBits bounds MessageIndex and MessageLimit, so that the
+// comparison of the two cannot wrap around the field.
+const MessageLimitBits = 32
+
+// RateLimitDomain is absorbed first into a rate-limit nullifier, so that it
+// is never a scoped nullifier over the same elements.
+var RateLimitDomain = encoding.DomainTag("rate-limit")
+
+// RateLimitCircuit is MembershipCircuit with the scoped nullifier replaced
+// by a rate-limit nullifier: Epoch, MessageIndex and MessageLimit are
+// public, MessageIndex < MessageLimit is enforced and Nullifier is
+// DeriveRateLimitNullifier. C stays private, so the proofs of one holder
+// are only linked within an epoch and message index, through the nullifier
+// the verifier records anyway. All other constraints are those of
+// MembershipCircuit.
+type RateLimitCircuit struct {
+
+	// Public inputs, in the order of MembershipCircuit with Scope replaced by
+	// the epoch, the message index and the limit
+	PolicyID        frontend.Variable `gnark:",public"`
+	Version         frontend.Variable `gnark:",public"`
+	CommitmentsRoot frontend.Variable `gnark:",public"` // Merkle root of all issued C
+	Threshold       frontend.Variable `gnark:",public"`
+	UpperBound      frontend.Variable `gnark:",public"`
+	IssuersRoot     frontend.Variable `gnark:",public"`
+	Epoch           frontend.Variable `gnark:",public"` // verifier-defined period, see nullifier.Epoch
+	MessageIndex    frontend.Variable `gnark:",public"` // 0 ≤ MessageIndex < MessageLimit
+	MessageLimit    frontend.Variable `gnark:",public"` // messages per holder and epoch
+	Nullifier       frontend.Variable `gnark:",public"`
+
+	// The commitment and its position in the tree
+	C               frontend.Variable
+	CommitmentIndex frontend.Variable
+	CommitmentPath  [CommitmentTreeDepth]frontend.Variable
+
+	// Private inputs of Circuit
+	Name       frontend.Variable
+	Age        frontend.Variable
+	Nation     frontend.Variable
+	Address    frontend.Variable
+	IdentityID frontend.Variable
+	AttrValue  frontend.Variable
+	DID        frontend.Variable
+	DIDVersion frontend.Variable
+	Secret     frontend.Variable
+	Salt       frontend.Variable
+
+	IssuerKey   eddsa.PublicKey
+	IssuerIndex frontend.Variable
+	IssuerPath  [IssuerTreeDepth]frontend.Variable
+	Signature   eddsa.Signature
+
+	// Compile-time configuration, not part of the witness
+	Policy Policy `gnark:"-"`
+}
+
+// NewRateLimitCircuit wraps the assignment of MembershipCircuit m, which
+// must have no scope, for message index of epoch under limit. nullifier must
+// be the holder's rate-limit nullifier for (epoch, index).
+func NewRateLimitCircuit(m *MembershipCircuit, epoch, index, limit, nullifier frontend.Variable) *RateLimitCircuit {
+	return &RateLimitCircuit{
+		PolicyID:        m.PolicyID,
+		Version:         m.Version,
+		CommitmentsRoot: m.CommitmentsRoot,
+		Threshold:       m.Threshold,
+		UpperBound:      m.UpperBound,
+		IssuersRoot:     m.IssuersRoot,
+		Epoch:           epoch,
+		MessageIndex:    index,
+		MessageLimit:    limit,
+		Nullifier:       nullifier,
+
+		C:               m.C,
+		CommitmentIndex: m.CommitmentIndex,
+		CommitmentPath:  m.CommitmentPath,
+
+		Name:       m.Name,
+		Age:        m.Age,
+		Nation:     m.Nation,
+		Address:    m.Address,
+		IdentityID: m.IdentityID,
+		AttrValue:  m.AttrValue,
+		DID:        m.DID,
+		DIDVersion: m.DIDVersion,
+		Secret:     m.Secret,
+		Salt:       m.Salt,
+
+		IssuerKey:   m.IssuerKey,
+		IssuerIndex: m.IssuerIndex,
+		IssuerPath:  m.IssuerPath,
+		Signature:   m.Signature,
+
+		Policy: m.Policy,
+	}
+}
+
+// Membership returns the view of r as a MembershipCircuit without a scope,
+// whose scoped nullifier is therefore the constant 0.
+func (r *RateLimitCircuit) Membership() *MembershipCircuit {
+	return &MembershipCircuit{
+		PolicyID:        r.PolicyID,
+		Version:         r.Version,
+		CommitmentsRoot: r.CommitmentsRoot,
+		Threshold:       r.Threshold,
+		UpperBound:      r.UpperBound,
+		IssuersRoot:     r.IssuersRoot,
+		Scope:           0,
+		Nullifier:       0,
+
+		C:               r.C,
+		CommitmentIndex: r.CommitmentIndex,
+		CommitmentPath:  r.CommitmentPath,
+
+		Name:       r.Name,
+		Age:        r.Age,
+		Nation:     r.Nation,
+		Address:    r.Address,
+		IdentityID: r.IdentityID,
+		AttrValue:  r.AttrValue,
+		DID:        r.DID,
+		DIDVersion: r.DIDVersion,
+		Secret:     r.Secret,
+		Salt:       r.Salt,
+
+		IssuerKey:   r.IssuerKey,
+		IssuerIndex: r.IssuerIndex,
+		IssuerPath:  r.IssuerPath,
+		Signature:   r.Signature,
+
+		Policy: r.Policy,
+	}
+}
+
+// DeriveRateLimitNullifier computes
+//
+//	Nullifier = MiMC(RateLimitDomain, DID, Secret, Epoch, MessageIndex)
+//
+// As with DeriveNullifier, the DID makes it per holder and the Secret of a
+// DIDv2 holder hides it from the issuer. Every index gives a different
+// nullifier, so one holder has exactly MessageLimit of them per epoch. The
+// out-of-circuit counterpart is proof_age.ComputeRateLimitNullifier.
+func (r *RateLimitCircuit) DeriveRateLimitNullifier(api frontend.API) (frontend.Variable, error) {
+	hasher, err := mimc.NewMiMC(api)
+	if err != nil {
+		return nil, err
+	}
+	hasher.Write(RateLimitDomain, r.DID, r.Secret, r.Epoch, r.MessageIndex)
+	return hasher.Sum(), nil
+}
+
+// Define enforces every constraint of MembershipCircuit, MessageIndex <
+// MessageLimit and the rate-limit nullifier.
+func (r *RateLimitCircuit) Define(api frontend.API) error {
+	if err := r.Membership().Define(api); err != nil {
+		return err
+	}
+
+	// -------------------------------------------------
+	// 6. Message index must be below the limit
+	// -------------------------------------------------
+	// both are range checked, so index + 1 cannot wrap to 0
+	api.ToBinary(r.MessageIndex, MessageLimitBits)
+	api.ToBinary(r.MessageLimit, MessageLimitBits)
+	api.AssertIsLessOrEqual(api.Add(r.MessageIndex, 1), r.MessageLimit)
+
+	// -------------------------------------------------
+	// 7. Rate-limit nullifier of (Epoch, MessageIndex)
+	// -------------------------------------------------
+	nullifier, err := r.DeriveRateLimitNullifier(api)
+	if err != nil {
+		return err
+	}
+	api.AssertIsEqual(nullifier, r.Nullifier)
+	return nil
+}
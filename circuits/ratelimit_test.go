@@ -0,0 +1,117 @@
+package circuits_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/merkle"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+)
+
+// TestRateLimitCircuit checks a full rate-limit witness and that C outside
+// the tree, an index at or above the limit, another epoch or nullifier and
+// a bad issuer signature do not satisfy the circuit.
+func TestRateLimitCircuit(t *testing.T) {
+	f := newFixture(t, 21)
+	epoch := big.NewInt(20_000)
+	msg := &proof_age.Message{Epoch: epoch, Index: 2, Limit: 3}
+	nullifierOf := func(index uint64) *big.Int {
+		return proof_age.ComputeRateLimitNullifier(f.cred.DID, f.secret, epoch, index)
+	}
+
+	// the tree without the credential's commitment
+	others, err := proof_age.NewCommitmentTree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, C := range []*big.Int{big.NewInt(11), big.NewInt(12), big.NewInt(13)} {
+		if _, err := others.Append(C); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(r *circuits.RateLimitCircuit)
+		solved bool
+	}{
+		{"valid", func(r *circuits.RateLimitCircuit) {}, true},
+		{"first message", func(r *circuits.RateLimitCircuit) {
+			r.MessageIndex, r.Nullifier = big.NewInt(0), nullifierOf(0)
+		}, true},
+		{"C not in the tree", func(r *circuits.RateLimitCircuit) {
+			path, err := others.Path(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			r.CommitmentsRoot = others.Root()
+			for i := range r.CommitmentPath {
+				r.CommitmentPath[i] = path[i]
+			}
+		}, false},
+		{"index at the limit", func(r *circuits.RateLimitCircuit) {
+			r.MessageIndex, r.Nullifier = big.NewInt(3), nullifierOf(3)
+		}, false},
+		{"index above the limit", func(r *circuits.RateLimitCircuit) {
+			r.MessageIndex, r.Nullifier = big.NewInt(7), nullifierOf(7)
+		}, false},
+		{"index wrapping around the field", func(r *circuits.RateLimitCircuit) {
+			minusOne := new(big.Int).Sub(fr.Modulus(), big.NewInt(1))
+			r.MessageIndex = minusOne
+			r.Nullifier = merkle.Hash(circuits.RateLimitDomain, f.cred.DID, f.secret, epoch, minusOne)
+		}, false},
+		{"wrong epoch", func(r *circuits.RateLimitCircuit) {
+			r.Epoch = new(big.Int).Add(epoch, big.NewInt(1))
+		}, false},
+		{"nullifier of another index", func(r *circuits.RateLimitCircuit) {
+			r.Nullifier = nullifierOf(0)
+		}, false},
+		{"scoped nullifier", func(r *circuits.RateLimitCircuit) {
+			r.Nullifier = proof_age.ComputeNullifier(f.cred.DID, f.secret, epoch)
+		}, false},
+		{"signature on another commitment", func(r *circuits.RateLimitCircuit) {
+			setSignature(t, &r.IssuerKey, &r.Signature, f.key, big.NewInt(1))
+		}, false},
+		{"issuer outside the set", func(r *circuits.RateLimitCircuit) {
+			setSignature(t, &r.IssuerKey, &r.Signature, f.outsider, f.cred.C)
+		}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := f.assignment(t, proof_age.ProofOptions{Commitments: f.commitments, Message: msg}).(*circuits.RateLimitCircuit)
+			tc.mutate(r)
+			err := f.isSolved(r)
+			if tc.solved && err != nil {
+				t.Fatalf("valid witness rejected: %v", err)
+			}
+			if !tc.solved && err == nil {
+				t.Fatal("invalid witness accepted")
+			}
+		})
+	}
+}
+
+// TestRateLimitCircuitHidesC checks that C is not among the public inputs.
+func TestRateLimitCircuitHidesC(t *testing.T) {
+	f := newFixture(t, 21)
+	msg := &proof_age.Message{Epoch: big.NewInt(20_000), Index: 0, Limit: 1}
+	r := f.assignment(t, proof_age.ProofOptions{Commitments: f.commitments, Message: msg})
+	w, err := frontend.NewWitness(r, ecc.BN254.ScalarField(), frontend.PublicOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	public := w.Vector().(fr.Vector)
+	if len(public) != 10 {
+		t.Fatalf("%d public inputs, want 10", len(public))
+	}
+	for i := range public {
+		if public[i].BigInt(new(big.Int)).Cmp(f.cred.C) == 0 {
+			t.Fatalf("public input %d is C", i)
+		}
+	}
+}
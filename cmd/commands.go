@@ -0,0 +1,975 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/kanthub/zkid-zkp/bundle"
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/issuer"
+	setup_keys "github.com/kanthub/zkid-zkp/keys"
+	"github.com/kanthub/zkid-zkp/merkle"
+	"github.com/kanthub/zkid-zkp/nullifier"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+	"github.com/kanthub/zkid-zkp/snarkjs"
+	"github.com/kanthub/zkid-zkp/solidity"
+	"github.com/kanthub/zkid-zkp/store"
+	verify_age "github.com/kanthub/zkid-zkp/verifier_mock"
+	"github.com/kanthub/zkid-zkp/zkid"
+)
+
+func runSetup(ctx context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("setup")
+	common.register(fs)
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	st, err := common.store()
+	if err != nil {
+		return err
+	}
+	setup, err := zkid.NewSetup(common.policyID)
+	if err != nil {
+		return err
+	}
+	policy := setup.Policy()
+	log.Printf("Compiling the circuit of policy %d (%s: %s) and running the Groth16 setup...",
+		policy.ID, policy.Name, policy.Predicate)
+	keys, err := setup.Run(ctx)
+	if err != nil {
+		return err
+	}
+	log.Printf("Setup completed: %d constraints", keys.CS.GetNbConstraints())
+	m, err := st.Save(keys.Policy, keys.CS, keys.PK, keys.VK)
+	if err != nil {
+		return err
+	}
+	log.Printf("Saved artifacts of policy %d v%d to %s", m.PolicyID, m.Version, st.Dir(m.PolicyID, m.Version))
+	return nil
+}
+
+func runDID(_ context.Context, args []string) error {
+	var holder holderFlags
+	fs := newFlagSet("did")
+	holder.register(fs)
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	did, err := holder.did()
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, []byte(did.String()+"\n"))
+}
+
+func runIssuerKeygen(_ context.Context, args []string) error {
+	fs := newFlagSet("issuer-keygen")
+	keyPath := fs.String("key", "", "private key output file (required)")
+	pubPath := fs.String("pub", "", "public key output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *keyPath == "" {
+		return errors.New("-key is required")
+	}
+	if _, err := os.Stat(*keyPath); err == nil {
+		return fmt.Errorf("%s exists, refusing to overwrite an issuer key", *keyPath)
+	}
+
+	key, err := issuer.GenerateKey()
+	if err != nil {
+		return err
+	}
+	if err := issuer.SaveKey(*keyPath, key); err != nil {
+		return err
+	}
+	if *pubPath == "" || *pubPath == "-" {
+		return writeOutput(*pubPath, []byte(issuer.FormatPublicKey(&key.PublicKey)+"\n"))
+	}
+	return issuer.SavePublicKey(*pubPath, &key.PublicKey)
+}
+
+func runIssuers(_ context.Context, args []string) error {
+	var add, remove listFlag
+	fs := newFlagSet("issuers")
+	setPath := fs.String("set", "", "trusted issuer set file, created if missing (required)")
+	fs.Var(&add, "add", "issuer public key file to add (repeatable)")
+	fs.Var(&remove, "remove", "issuer public key file to remove (repeatable)")
+	out := fs.String("out", "", "root output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *setPath == "" {
+		return errors.New("-set is required")
+	}
+
+	set, err := issuer.LoadSet(*setPath)
+	if errors.Is(err, os.ErrNotExist) {
+		set, err = issuer.NewSet()
+	}
+	if err != nil {
+		return err
+	}
+	for _, path := range remove {
+		pub, err := issuer.LoadPublicKey(path)
+		if err != nil {
+			return err
+		}
+		if err := set.Remove(pub); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	for _, path := range add {
+		pub, err := issuer.LoadPublicKey(path)
+		if err != nil {
+			return err
+		}
+		slot, err := set.Add(pub)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		log.Printf("Added issuer %s in slot %d", issuer.FormatPublicKey(pub), slot)
+	}
+	if len(add) > 0 || len(remove) > 0 {
+		if err := issuer.SaveSet(*setPath, set); err != nil {
+			return err
+		}
+	}
+	log.Printf("%d trusted issuers", len(set.Keys()))
+	return writeOutput(*out, []byte(set.Root().String()+"\n"))
+}
+
+func runCommitments(_ context.Context, args []string) error {
+	var add listFlag
+	fs := newFlagSet("commitments")
+	treePath := fs.String("tree", "", "commitment tree file, created if missing (required)")
+	fs.Var(&add, "add", "commitment C to append (repeatable)")
+	out := fs.String("out", "", "root output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *treePath == "" {
+		return errors.New("-tree is required")
+	}
+
+	tree, err := loadCommitmentTree(*treePath)
+	if err != nil {
+		return err
+	}
+	for _, s := range add {
+		C, err := parseBig(s)
+		if err != nil {
+			return fmt.Errorf("-add: %w", err)
+		}
+		index, err := tree.Append(C)
+		if err != nil {
+			return err
+		}
+		log.Printf("Appended commitment at index %d", index)
+	}
+	if len(add) > 0 {
+		if err := merkle.Save(*treePath, tree); err != nil {
+			return err
+		}
+	}
+	log.Printf("%d issued commitments", tree.Len())
+	return writeOutput(*out, []byte(tree.Root().String()+"\n"))
+}
+
+// loadCommitmentTree reads a commitment tree, or returns an empty one if
+// path does not exist.
+func loadCommitmentTree(path string) (*merkle.Tree, error) {
+	tree, err := merkle.Load(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return proof_age.NewCommitmentTree()
+	}
+	return tree, err
+}
+
+func runCommit(_ context.Context, args []string) error {
+	var (
+		common commonFlags
+		holder holderFlags
+		did    bigFlag
+	)
+	fs := newFlagSet("commit")
+	common.register(fs)
+	holder.registerAttributes(fs)
+	fs.Var(&did, "did", "DID presented by the holder (default: DIDv1 computed from the attributes)")
+	didVersion := fs.String("did-version", "v1", "v1, or v2 for a secret-seeded DID given with -did")
+	saltFile := fs.String("salt-file", "", "salt file (required)")
+	newSalt := fs.Bool("new-salt", false, "generate a fresh salt into -salt-file")
+	out := fs.String("out", "", "output file (default stdout)")
+	credentialOut := fs.String("credential-out", "", "also write the credential file for the holder")
+	issuerKey := fs.String("issuer-key", "", "issuer private key file; signs the commitment")
+	commitments := fs.String("commitments", "", "commitment tree file to append C to (membership and rate-limit policies)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *saltFile == "" {
+		return errors.New("-salt-file is required")
+	}
+
+	policy, err := common.policy()
+	if err != nil {
+		return err
+	}
+	if *newSalt {
+		if _, err := os.Stat(*saltFile); err == nil {
+			return fmt.Errorf("%s exists, refusing to overwrite a salt", *saltFile)
+		}
+		salt, err := proof_age.GenerateSalt()
+		if err != nil {
+			return err
+		}
+		if err := proof_age.SaveSalt(*saltFile, salt); err != nil {
+			return err
+		}
+	}
+	cred, err := issuedCredential(policy, &holder, *didVersion, did.v, *saltFile)
+	if err != nil {
+		return err
+	}
+	if err := signCredential(cred, *issuerKey); err != nil {
+		return err
+	}
+	if *commitments != "" {
+		tree, err := loadCommitmentTree(*commitments)
+		if err != nil {
+			return err
+		}
+		index, err := tree.Append(cred.C)
+		if err != nil {
+			return err
+		}
+		if err := merkle.Save(*commitments, tree); err != nil {
+			return err
+		}
+		log.Printf("Appended commitment at index %d", index)
+	}
+	if *credentialOut != "" {
+		if err := proof_age.SaveCredential(*credentialOut, cred); err != nil {
+			return err
+		}
+	}
+	return writeOutput(*out, []byte(cred.C.String()+"\n"))
+}
+
+// issuedCredential computes the commitment C of a holder given by flags on
+// the issuer side: a DIDv2 DID is taken as presented, as the issuer never
+// sees the holder secret.
+func issuedCredential(policy circuits.Policy, holder *holderFlags, didVersion string, did *big.Int, saltFile string) (*proof_age.Credential, error) {
+	var version proof_age.DIDVersion
+	switch didVersion {
+	case "v1":
+		version = proof_age.DIDv1
+	case "v2":
+		version = proof_age.DIDv2
+	default:
+		return nil, fmt.Errorf("unknown -did-version %q", didVersion)
+	}
+	attr, err := holder.attrValue()
+	if err != nil {
+		return nil, err
+	}
+	if did == nil {
+		if version == proof_age.DIDv2 {
+			return nil, errors.New("-did is required for a DIDv2 holder")
+		}
+		if did, err = holder.did(); err != nil {
+			return nil, err
+		}
+	}
+	salt, err := proof_age.LoadSalt(saltFile)
+	if err != nil {
+		return nil, err
+	}
+	cred := &proof_age.Credential{
+		PolicyID:   policy.ID,
+		Version:    policy.Version,
+		Name:       holder.name,
+		Nation:     holder.nation,
+		Address:    holder.address,
+		Age:        holder.age,
+		IdentityID: holder.identityID,
+		AttrValue:  attr,
+		DIDVersion: version,
+		DID:        did,
+		Salt:       salt,
+	}
+	if err := cred.Commit(); err != nil {
+		return nil, err
+	}
+	return cred, nil
+}
+
+// holderCredential computes the DID (unless given) and the commitment C of a
+// holder given by flags, on the holder side.
+func holderCredential(policy circuits.Policy, holder *holderFlags, did *big.Int, saltFile string) (*proof_age.Credential, error) {
+	var err error
+	if did == nil {
+		if did, err = holder.did(); err != nil {
+			return nil, err
+		}
+	}
+	salt, err := proof_age.LoadSalt(saltFile)
+	if err != nil {
+		return nil, err
+	}
+	attr, err := holder.attrValue()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := holder.secret()
+	if err != nil {
+		return nil, err
+	}
+	return proof_age.NewCredential(
+		policy,
+		holder.name, holder.nation, holder.address,
+		holder.age, holder.identityID,
+		attr, secret,
+		did, salt,
+	)
+}
+
+// signCredential signs cred with the issuer key in keyPath, if any.
+func signCredential(cred *proof_age.Credential, keyPath string) error {
+	if keyPath == "" {
+		return nil
+	}
+	key, err := issuer.LoadKey(keyPath)
+	if err != nil {
+		return err
+	}
+	return cred.Sign(key)
+}
+
+func runProve(ctx context.Context, args []string) error {
+	var (
+		common                commonFlags
+		holder                holderFlags
+		did                   bigFlag
+		threshold, upperBound bigFlag
+		scope                 bigFlag
+	)
+	fs := newFlagSet("prove")
+	common.register(fs)
+	holder.register(fs)
+	credentialPath := fs.String("credential", "", "credential file (replaces the attribute, -did and -salt-file flags)")
+	fs.Var(&did, "did", "DID issued by the oracle (default: computed from the attributes)")
+	fs.Var(&threshold, "threshold", "public threshold of the predicate")
+	fs.Var(&upperBound, "upper", "public upper bound (range predicates only)")
+	saltFile := fs.String("salt-file", "", "salt file (required without -credential)")
+	issuerKey := fs.String("issuer-key", "", "issuer private key file, signs the commitment (without -credential)")
+	issuersPath := fs.String("issuers", "", "trusted issuer set of the verifier (required)")
+	commitmentsPath := fs.String("commitments", "", "issued commitment tree (membership and rate-limit policies)")
+	fs.Var(&scope, "scope", "nullifier scope chosen by the verifier (default: no nullifier)")
+	period := fs.Duration("period", 0, "epoch length of the verifier (rate-limit policies, with -scope)")
+	messageIndex := fs.Uint64("message-index", 0, "message index in the current epoch (rate-limit policies)")
+	messageLimit := fs.Uint64("message-limit", 0, "messages per epoch allowed by the verifier (rate-limit policies)")
+	out := fs.String("out", "", "proof bundle output file (required)")
+	format := fs.String("format", "binary", "proof bundle encoding: binary or json")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("-out is required")
+	}
+	bundleFormat, err := parseFormat(*format)
+	if err != nil {
+		return err
+	}
+	if threshold.v == nil {
+		return errors.New("-threshold is required")
+	}
+	if *issuersPath == "" {
+		return errors.New("-issuers is required")
+	}
+	if *period != 0 {
+		if err := nullifier.CheckPeriod(*period); err != nil {
+			return err
+		}
+	}
+	issuers, err := issuer.LoadSet(*issuersPath)
+	if err != nil {
+		return err
+	}
+	var commitments *merkle.Tree
+	if *commitmentsPath != "" {
+		if commitments, err = merkle.Load(*commitmentsPath); err != nil {
+			return err
+		}
+	}
+
+	var cred *proof_age.Credential
+	if *credentialPath != "" {
+		c, err := proof_age.LoadCredential(*credentialPath)
+		if err != nil {
+			return err
+		}
+		cred = c
+	} else {
+		if *saltFile == "" {
+			return errors.New("-salt-file or -credential is required")
+		}
+		policy, err := common.policy()
+		if err != nil {
+			return err
+		}
+		if cred, err = holderCredential(policy, &holder, did.v, *saltFile); err != nil {
+			return err
+		}
+		if err := signCredential(cred, *issuerKey); err != nil {
+			return err
+		}
+	}
+	secret, err := holder.secret()
+	if err != nil {
+		return err
+	}
+
+	st, err := common.store()
+	if err != nil {
+		return err
+	}
+	prover, err := zkid.NewProverFromStore(ctx, st, cred.PolicyID)
+	if err != nil {
+		return err
+	}
+	var msg *zkid.Message
+	if prover.Policy().Mode == circuits.ModeRateLimit {
+		if scope.v == nil || *period == 0 || *messageLimit == 0 {
+			return errors.New("rate-limit policies need -scope, -period and -message-limit")
+		}
+		epoch, err := nullifier.Epoch(scope.v, *period, time.Now())
+		if err != nil {
+			return err
+		}
+		msg = &zkid.Message{
+			Epoch: epoch,
+			Index: *messageIndex,
+			Limit: *messageLimit,
+		}
+		scope.v = nil // the scope only selects the epoch
+	}
+	proof, err := prover.ProveCredential(ctx, cred, zkid.ProofOptions{
+		Issuers:     issuers,
+		Commitments: commitments,
+		Secret:      secret,
+		Threshold:   threshold.v,
+		UpperBound:  upperBound.v,
+		Scope:       scope.v,
+		Message:     msg,
+	})
+	if err != nil {
+		return err
+	}
+	switch {
+	case msg != nil:
+		log.Printf("Nullifier of message %d/%d in epoch %s: %s",
+			msg.Index, msg.Limit, proof.Public.Epoch, proof.Public.Nullifier)
+	case proof.Public.Nullifier.Sign() != 0:
+		log.Printf("Nullifier in scope %s: %s", proof.Public.Scope, proof.Public.Nullifier)
+	}
+
+	vk, err := st.LoadVerifyingKey(cred.PolicyID, cred.Version)
+	if err != nil {
+		return err
+	}
+	b, err := proof.Bundle(vk)
+	if err != nil {
+		return err
+	}
+	if err := bundle.Save(*out, b, bundleFormat); err != nil {
+		return err
+	}
+	log.Printf("Saved proof bundle of policy %d v%d to %s", b.PolicyID, b.Version, *out)
+
+	p, err := solidity.FromGroth16(b.Proof)
+	if err != nil {
+		return err
+	}
+	c, err := p.Compress()
+	if err != nil {
+		return err
+	}
+	log.Printf("uint256[8] proof: %s", p.Hex())
+	log.Printf("uint256[4] compressed proof: %s", c.Hex())
+	return nil
+}
+
+func runVerify(ctx context.Context, args []string) error {
+	var (
+		common      commonFlags
+		issuers     issuerSetsFlag
+		commitments commitmentTreesFlag
+		scope       bigFlag
+
+		threshold, upperBound bigFlag
+	)
+	fs := newFlagSet("verify")
+	common.register(fs)
+	version := fs.Int64("version", 0, "policy version the proof must be for (required, with -policy)")
+	fs.Var(&threshold, "threshold", "threshold the proof must be for (required)")
+	fs.Var(&upperBound, "upper", "upper bound the proof must be for (required for range predicates)")
+	fs.Var(&issuers, "issuers", "trusted issuer set file (required, repeatable)")
+	fs.Var(&commitments, "commitments", "accepted commitment tree file (membership and rate-limit policies, repeatable)")
+	fs.Var(&scope, "scope", "nullifier scope; accept one proof per holder (needs -nullifiers, required for rate-limit policies)")
+	nullifiersPath := fs.String("nullifiers", "", "nullifier log file, created if missing (required for rate-limit policies)")
+	period := fs.Duration("period", 0, "epoch length (required for rate-limit policies only)")
+	messageLimit := fs.Uint64("message-limit", 0, "messages per holder and epoch (required for rate-limit policies only)")
+	proofPath := fs.String("proof", "", "proof bundle file, binary or JSON (required)")
+	vkPath := fs.String("vk", "", "verifying key file (default: from the artifact store)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *proofPath == "" {
+		return errors.New("-proof is required")
+	}
+	policy, err := pinnedPolicy(fs, &common, *version)
+	if err != nil {
+		return err
+	}
+	if len(issuers.sets) == 0 {
+		return errors.New("-issuers is required")
+	}
+	if threshold.v == nil {
+		return errors.New("-threshold is required")
+	}
+	if (scope.v == nil) != (*nullifiersPath == "") {
+		return errors.New("-scope and -nullifiers go together")
+	}
+	if policy.Mode == circuits.ModeRateLimit {
+		// without a limiter any epoch and message index would be accepted
+		if err := requireFlags(fs, "scope", "nullifiers", "period", "message-limit"); err != nil {
+			return fmt.Errorf("policy %d is rate-limited: %w", policy.ID, err)
+		}
+		if err := nullifier.CheckPeriod(*period); err != nil {
+			return err
+		}
+	} else if err := rejectFlags(fs, "period", "message-limit"); err != nil {
+		return fmt.Errorf("policy %d is not rate-limited: %w", policy.ID, err)
+	}
+
+	b, err := bundle.Load(*proofPath)
+	if err != nil {
+		return err
+	}
+	if b.PolicyID != policy.ID || b.Version != policy.Version {
+		return fmt.Errorf("%s is a proof for policy %d v%d, not policy %d v%d",
+			*proofPath, b.PolicyID, b.Version, policy.ID, policy.Version)
+	}
+	vk, err := loadVK(&common, policy.ID, policy.Version, *vkPath)
+	if err != nil {
+		return err
+	}
+	if err := requireUpper(policy.ID, &upperBound); err != nil {
+		return err
+	}
+	bounds := zkid.Bounds{Threshold: threshold.v, UpperBound: upperBound.orZero()}
+	verifier, err := zkid.NewVerifier(policy.ID, vk, bounds, issuers.sets...)
+	if err != nil {
+		return err
+	}
+	verifier.AcceptCommitmentRoots(commitments.roots()...)
+	if *nullifiersPath != "" {
+		store, err := nullifier.OpenFileStore(*nullifiersPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		if policy.Mode == circuits.ModeRateLimit {
+			limiter, err := nullifier.NewRateLimiter(scope.v, *period, *messageLimit, store)
+			if err != nil {
+				return err
+			}
+			if err := verifier.UseRateLimit(limiter); err != nil {
+				return err
+			}
+		} else if err := verifier.UseNullifiers(scope.v, store); err != nil {
+			return err
+		}
+	}
+	if err := verifier.VerifyBundle(ctx, b); err != nil {
+		return err
+	}
+	log.Printf("Verification SUCCESS: policy %d v%d", b.PolicyID, b.Version)
+	return nil
+}
+
+func runExportSolidity(_ context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("export-solidity")
+	common.register(fs)
+	vkPath := fs.String("vk", "", "verifying key file (default: from the artifact store)")
+	out := fs.String("out", "", "output .sol file (required)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *out == "" {
+		return errors.New("-out is required")
+	}
+
+	policy, err := common.policy()
+	if err != nil {
+		return err
+	}
+	vk, err := loadVK(&common, policy.ID, policy.Version, *vkPath)
+	if err != nil {
+		return err
+	}
+	return setup_keys.ExportSolidity(*out, vk)
+}
+
+func runExportProof(_ context.Context, args []string) error {
+	fs := newFlagSet("export-proof")
+	proofPath := fs.String("proof", "", "proof bundle file (required)")
+	format := fs.String("format", "json", "json (both proof forms and the inputs) or hex (one proof form)")
+	compressed := fs.Bool("compressed", false, "with -format hex, output the 128-byte compressed proof")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *proofPath == "" {
+		return errors.New("-proof is required")
+	}
+
+	b, err := bundle.Load(*proofPath)
+	if err != nil {
+		return err
+	}
+	export, err := solidity.NewExport(b)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	switch *format {
+	case "json":
+		if data, err = json.MarshalIndent(export, "", "  "); err != nil {
+			return err
+		}
+	case "hex":
+		if *compressed {
+			data = []byte(export.CompressedProof.Hex())
+		} else {
+			data = []byte(export.Proof.Hex())
+		}
+	default:
+		return fmt.Errorf("unknown -format %q", *format)
+	}
+	return writeOutput(*out, append(data, '\n'))
+}
+
+func runCalldata(_ context.Context, args []string) error {
+	fs := newFlagSet("calldata")
+	proofPath := fs.String("proof", "", "proof bundle file")
+	compressed := fs.Bool("compressed", false, "encode verifyCompressedProof instead of verifyProof")
+	solPath := fs.String("sol", "", "verifier source whose input count must match")
+	decode := fs.String("decode", "", "decode this calldata (hex) instead of encoding; needs -sol")
+	out := fs.String("out", "", "output file (default stdout)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	nInputs := -1
+	if *solPath != "" {
+		src, err := os.ReadFile(*solPath)
+		if err != nil {
+			return err
+		}
+		if nInputs, err = solidity.InputCount(src); err != nil {
+			return fmt.Errorf("%s: %w", *solPath, err)
+		}
+	}
+
+	if *decode != "" {
+		if nInputs < 0 {
+			return errors.New("-decode needs -sol")
+		}
+		data, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(*decode), "0x"))
+		if err != nil {
+			return err
+		}
+		var decoded any
+		if *compressed {
+			c, input, err := solidity.DecodeVerifyCompressedProofCalldata(data, nInputs)
+			if err != nil {
+				return err
+			}
+			decoded = map[string]any{"compressed_proof": c, "input": input}
+		} else {
+			p, input, err := solidity.DecodeVerifyProofCalldata(data, nInputs)
+			if err != nil {
+				return err
+			}
+			decoded = map[string]any{"proof": p, "input": input}
+		}
+		js, err := json.MarshalIndent(decoded, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeOutput(*out, append(js, '\n'))
+	}
+
+	if *proofPath == "" {
+		return errors.New("-proof or -decode is required")
+	}
+	b, err := bundle.Load(*proofPath)
+	if err != nil {
+		return err
+	}
+	if nInputs >= 0 && nInputs != len(b.PublicInputs) {
+		return fmt.Errorf("%s takes %d public inputs, the proof has %d", *solPath, nInputs, len(b.PublicInputs))
+	}
+	export, err := solidity.NewExport(b)
+	if err != nil {
+		return err
+	}
+	call := export.Calldata
+	if *compressed {
+		call = export.CompressedCalldata
+	}
+	return writeOutput(*out, []byte(call+"\n"))
+}
+
+func runExportSnarkJS(_ context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("export-snarkjs")
+	common.register(fs)
+	proofPath := fs.String("proof", "", "proof bundle file (required)")
+	vkPath := fs.String("vk", "", "verifying key file (default: from the artifact store)")
+	dir := fs.String("dir", "", "output directory for proof.json, public.json and verification_key.json (required)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	if *proofPath == "" || *dir == "" {
+		return errors.New("-proof and -dir are required")
+	}
+
+	b, err := bundle.Load(*proofPath)
+	if err != nil {
+		return err
+	}
+	vk, err := loadVK(&common, b.PolicyID, b.Version, *vkPath)
+	if err != nil {
+		return err
+	}
+	return snarkjs.WriteFiles(*dir, b.Proof, b.PublicInputs, vk)
+}
+
+func runVerifySnarkJS(ctx context.Context, args []string) error {
+	var (
+		common      commonFlags
+		issuers     issuerSetsFlag
+		commitments commitmentTreesFlag
+
+		threshold, upperBound bigFlag
+	)
+	fs := newFlagSet("verify-snarkjs")
+	common.register(fs)
+	version := fs.Int64("version", 0, "policy version the proof must be for (required, with -policy)")
+	fs.Var(&threshold, "threshold", "threshold the proof must be for (required)")
+	fs.Var(&upperBound, "upper", "upper bound the proof must be for (required for range predicates)")
+	fs.Var(&issuers, "issuers", "trusted issuer set file (required, repeatable)")
+	fs.Var(&commitments, "commitments", "accepted commitment tree file (membership and rate-limit policies, repeatable)")
+	dir := fs.String("dir", ".", "directory holding the snarkjs files")
+	proofPath := fs.String("proof", "", "proof.json (default: in -dir)")
+	publicPath := fs.String("public", "", "public.json (default: in -dir)")
+	vkPath := fs.String("vk", "", "verification_key.json that must match the stored verifying key (default: not read)")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+	policy, err := pinnedPolicy(fs, &common, *version)
+	if err != nil {
+		return err
+	}
+	if len(issuers.sets) == 0 {
+		return errors.New("-issuers is required")
+	}
+	if threshold.v == nil {
+		return errors.New("-threshold is required")
+	}
+	if *proofPath == "" {
+		*proofPath = filepath.Join(*dir, snarkjs.ProofFile)
+	}
+	if *publicPath == "" {
+		*publicPath = filepath.Join(*dir, snarkjs.PublicFile)
+	}
+
+	proof, public, err := snarkjs.ReadProof(*proofPath, *publicPath)
+	if err != nil {
+		return err
+	}
+	pub, err := verify_age.PublicInputsFromVector(public)
+	if err != nil {
+		return err
+	}
+	if pub.PolicyID != policy.ID || pub.Version != policy.Version {
+		return fmt.Errorf("%s is for policy %d v%d, not policy %d v%d",
+			*publicPath, pub.PolicyID, pub.Version, policy.ID, policy.Version)
+	}
+	st, err := common.store()
+	if err != nil {
+		return err
+	}
+	vk, err := st.LoadVerifyingKey(policy.ID, policy.Version)
+	if err != nil {
+		return err
+	}
+	if *vkPath != "" {
+		if err := matchSnarkJSKey(*vkPath, vk); err != nil {
+			return err
+		}
+	}
+	if err := requireUpper(policy.ID, &upperBound); err != nil {
+		return err
+	}
+	bounds := zkid.Bounds{Threshold: threshold.v, UpperBound: upperBound.orZero()}
+	verifier, err := zkid.NewVerifier(policy.ID, vk, bounds, issuers.sets...)
+	if err != nil {
+		return err
+	}
+	verifier.AcceptCommitmentRoots(commitments.roots()...)
+	if err := verifier.Verify(ctx, &zkid.Proof{Proof: proof, Public: pub}); err != nil {
+		return err
+	}
+	log.Printf("Verification SUCCESS: policy %d v%d", pub.PolicyID, pub.Version)
+	return nil
+}
+
+// matchSnarkJSKey checks that the verification_key.json in path is the
+// trusted key vk.
+func matchSnarkJSKey(path string, vk groth16.VerifyingKey) error {
+	given, err := snarkjs.ReadVerifyingKey(path)
+	if err != nil {
+		return err
+	}
+	got, err := snarkjs.FingerprintOf(given)
+	if err != nil {
+		return err
+	}
+	want, err := snarkjs.FingerprintOf(vk)
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("%s is not the stored verifying key (fingerprint %x, want %x)", path, got, want)
+	}
+	return nil
+}
+
+func runInspect(_ context.Context, args []string) error {
+	var common commonFlags
+	fs := newFlagSet("inspect")
+	common.register(fs)
+	all := fs.Bool("all", false, "show every stored policy version")
+	if err := parseFlags(fs, args); err != nil {
+		return err
+	}
+
+	type policyInfo struct {
+		ID        int64  `json:"policy_id"`
+		Version   int64  `json:"version"`
+		Name      string `json:"name"`
+		Predicate string `json:"predicate"`
+		Mode      string `json:"mode"`
+	}
+	report := struct {
+		Policies  []policyInfo      `json:"policies"`
+		Artifacts []*store.Manifest `json:"artifacts"`
+	}{}
+	for _, p := range circuits.Policies() {
+		if *all || p.ID == common.policyID {
+			report.Policies = append(report.Policies, policyInfo{p.ID, p.Version, p.Name, p.Predicate.String(), p.Mode.String()})
+		}
+	}
+
+	st, err := common.store()
+	if err != nil {
+		return err
+	}
+	manifests, err := st.List()
+	if err != nil {
+		return err
+	}
+	for _, m := range manifests {
+		if *all || m.PolicyID == common.policyID {
+			report.Artifacts = append(report.Artifacts, m)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(report)
+}
+
+// pinnedPolicy returns the policy a verifier pins with -policy and -version.
+// Both are required: the proof never chooses the statement it is checked
+// against.
+func pinnedPolicy(fs *flag.FlagSet, common *commonFlags, version int64) (circuits.Policy, error) {
+	if err := requireFlags(fs, "policy", "version"); err != nil {
+		return circuits.Policy{}, err
+	}
+	policy, err := common.policy()
+	if err != nil {
+		return circuits.Policy{}, err
+	}
+	if version != policy.Version {
+		return circuits.Policy{}, fmt.Errorf("policy %d is registered at v%d, not v%d", policy.ID, policy.Version, version)
+	}
+	return policy, nil
+}
+
+// requireUpper checks that -upper is given for a range policy.
+func requireUpper(policyID int64, upperBound *bigFlag) error {
+	policy, err := circuits.LookupPolicy(policyID)
+	if err != nil {
+		return err
+	}
+	if policy.Predicate.Operator == circuits.OpInRange && upperBound.v == nil {
+		return fmt.Errorf("-upper is required for policy %d (%s)", policy.ID, policy.Predicate)
+	}
+	return nil
+}
+
+// loadVK reads the verifying key from vkPath, or from the artifact store.
+func loadVK(common *commonFlags, policyID, version int64, vkPath string) (groth16.VerifyingKey, error) {
+	if vkPath != "" {
+		return setup_keys.LoadVerifyingKey(vkPath)
+	}
+	st, err := common.store()
+	if err != nil {
+		return nil, err
+	}
+	return st.LoadVerifyingKey(policyID, version)
+}
+
+// parseFormat maps a -format flag to a bundle encoding.
+func parseFormat(s string) (bundle.Format, error) {
+	switch s {
+	case "binary", "bin":
+		return bundle.Binary, nil
+	case "json":
+		return bundle.JSON, nil
+	default:
+		return 0, fmt.Errorf("unknown -format %q", s)
+	}
+}
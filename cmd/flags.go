@@ -0,0 +1,298 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/issuer"
+	"github.com/kanthub/zkid-zkp/merkle"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+	"github.com/kanthub/zkid-zkp/store"
+)
+
+// newFlagSet returns a flag set that reports errors instead of exiting.
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet("zkid "+name, flag.ContinueOnError)
+	return fs
+}
+
+// parseFlags parses args, then fills every flag that was not given on the
+// command line from the JSON object in the file named by -input, if any.
+func parseFlags(fs *flag.FlagSet, args []string) error {
+	input := fs.String("input", "", "JSON file whose keys are flag names")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %v", fs.Args())
+	}
+	if *input == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(*input)
+	if err != nil {
+		return err
+	}
+	var values map[string]json.RawMessage
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("%s: %w", *input, err)
+	}
+
+	explicit := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	for key, raw := range values {
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("%s: unknown key %q", *input, key)
+		}
+		if explicit[key] {
+			continue
+		}
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			// numbers and booleans are used verbatim
+			s = strings.TrimSpace(string(raw))
+		}
+		if err := fs.Set(key, s); err != nil {
+			return fmt.Errorf("%s: %s: %w", *input, key, err)
+		}
+	}
+	return nil
+}
+
+// requireFlags returns an error for the first of names that was given
+// neither on the command line nor through -input.
+func requireFlags(fs *flag.FlagSet, names ...string) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range names {
+		if !set[name] {
+			return fmt.Errorf("-%s is required", name)
+		}
+	}
+	return nil
+}
+
+// rejectFlags returns an error for the first of names that was given, on
+// the command line or through -input, to a command that would ignore it.
+func rejectFlags(fs *flag.FlagSet, names ...string) error {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, name := range names {
+		if set[name] {
+			return fmt.Errorf("-%s does not apply", name)
+		}
+	}
+	return nil
+}
+
+// bigFlag is a flag.Value holding a field element, given in decimal or as
+// 0x-prefixed hex.
+type bigFlag struct {
+	v *big.Int
+}
+
+func (b *bigFlag) String() string {
+	if b == nil || b.v == nil {
+		return ""
+	}
+	return b.v.String()
+}
+
+func (b *bigFlag) Set(s string) error {
+	v, err := parseBig(s)
+	if err != nil {
+		return err
+	}
+	b.v = v
+	return nil
+}
+
+// parseBig reads a field element the way credential files spell it (see
+// proof_age.ParseElement) and rejects values outside [0, r).
+func parseBig(s string) (*big.Int, error) {
+	v, ok := proof_age.ParseElement(strings.TrimSpace(s))
+	if !ok {
+		return nil, fmt.Errorf("invalid integer %q: want decimal or 0x hex", s)
+	}
+	if v.Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("%s is not in [0, r)", s)
+	}
+	return v, nil
+}
+
+// orZero returns the flag value or 0 when unset.
+func (b *bigFlag) orZero() *big.Int {
+	if b.v == nil {
+		return big.NewInt(0)
+	}
+	return b.v
+}
+
+// listFlag is a repeatable string flag.
+type listFlag []string
+
+func (f *listFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *listFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// issuerSetsFlag is a repeatable flag of trusted issuer set files.
+type issuerSetsFlag struct {
+	paths []string
+	sets  []*issuer.Set
+}
+
+func (f *issuerSetsFlag) String() string {
+	return strings.Join(f.paths, ",")
+}
+
+func (f *issuerSetsFlag) Set(path string) error {
+	s, err := issuer.LoadSet(path)
+	if err != nil {
+		return err
+	}
+	f.paths = append(f.paths, path)
+	f.sets = append(f.sets, s)
+	return nil
+}
+
+// commitmentTreesFlag is a repeatable flag of commitment tree files.
+type commitmentTreesFlag struct {
+	paths []string
+	trees []*merkle.Tree
+}
+
+func (f *commitmentTreesFlag) String() string {
+	return strings.Join(f.paths, ",")
+}
+
+func (f *commitmentTreesFlag) Set(path string) error {
+	t, err := merkle.Load(path)
+	if err != nil {
+		return err
+	}
+	f.paths = append(f.paths, path)
+	f.trees = append(f.trees, t)
+	return nil
+}
+
+// roots returns the roots of the trees.
+func (f *commitmentTreesFlag) roots() []*big.Int {
+	out := make([]*big.Int, len(f.trees))
+	for i, t := range f.trees {
+		out[i] = t.Root()
+	}
+	return out
+}
+
+// commonFlags are shared by the commands that touch artifacts.
+type commonFlags struct {
+	policyID  int64
+	artifacts string
+}
+
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	fs.Int64Var(&c.policyID, "policy", 1, "policy id")
+	fs.StringVar(&c.artifacts, "artifacts", "artifacts", "artifact store directory")
+}
+
+func (c *commonFlags) policy() (circuits.Policy, error) {
+	return circuits.LookupPolicy(c.policyID)
+}
+
+func (c *commonFlags) store() (*store.Store, error) {
+	return store.Open(c.artifacts)
+}
+
+// holderFlags are the private attributes of a holder.
+type holderFlags struct {
+	name, nation, address string
+	age, identityID       int64
+	attrHex               string
+	secretFile            string
+	didVersion            string
+}
+
+func (h *holderFlags) register(fs *flag.FlagSet) {
+	h.registerAttributes(fs)
+	fs.StringVar(&h.secretFile, "secret-file", "", "holder secret file (DIDv2); omit for DIDv1")
+	fs.StringVar(&h.didVersion, "did-version", "", "legacy, v1 or v2 (default: v2 with -secret-file, v1 otherwise)")
+}
+
+// registerAttributes registers the attribute flags only, for the issuer,
+// who never handles the holder secret.
+func (h *holderFlags) registerAttributes(fs *flag.FlagSet) {
+	fs.StringVar(&h.name, "name", "", "holder name")
+	fs.StringVar(&h.nation, "nation", "", "holder nationality")
+	fs.StringVar(&h.address, "address", "", "holder address")
+	fs.Int64Var(&h.age, "age", 0, "holder age in years")
+	fs.Int64Var(&h.identityID, "identity-id", 0, "identity number")
+	fs.StringVar(&h.attrHex, "attr", "", "biometric attribute bytes, hex")
+}
+
+func (h *holderFlags) attrValue() ([]byte, error) {
+	b, err := hex.DecodeString(strings.TrimPrefix(h.attrHex, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("-attr: %w", err)
+	}
+	return b, nil
+}
+
+// secret loads the holder secret, or returns nil without -secret-file.
+func (h *holderFlags) secret() (*big.Int, error) {
+	if h.secretFile == "" {
+		return nil, nil
+	}
+	return proof_age.LoadSecret(h.secretFile)
+}
+
+// did computes the holder DID with the selected scheme.
+func (h *holderFlags) did() (*big.Int, error) {
+	attr, err := h.attrValue()
+	if err != nil {
+		return nil, err
+	}
+	secret, err := h.secret()
+	if err != nil {
+		return nil, err
+	}
+	var version proof_age.DIDVersion
+	switch h.didVersion {
+	case "":
+		version = proof_age.DIDv1
+		if secret != nil {
+			version = proof_age.DIDv2
+		}
+	case "legacy":
+		version = proof_age.DIDLegacy
+	case "v1":
+		version = proof_age.DIDv1
+	case "v2":
+		version = proof_age.DIDv2
+	default:
+		return nil, fmt.Errorf("unknown -did-version %q", h.didVersion)
+	}
+	return proof_age.ComputeLocalDID(version, h.name, h.nation, h.address, h.age, h.identityID, attr, secret)
+}
+
+// writeOutput writes data to path, or to stdout when path is empty or "-".
+func writeOutput(path string, data []byte) error {
+	if path == "" || path == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
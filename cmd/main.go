@@ -1,49 +1,99 @@
+// zkid command line: scriptable setup, DID / commitment computation,
+// proving and verification.
+//
+//	zkid setup            -policy 1
+//	zkid issuer-keygen    -key issuer.key -pub issuer.pub
+//	zkid issuers          -set issuers.json -add issuer.pub [-remove old.pub]
+//	zkid did              -input holder.json
+//	zkid commit           -input holder.json -salt-file salt.hex -new-salt -issuer-key issuer.key -credential-out credential.json [-did-version v2 -did <holder DID>] [-commitments tree.json]
+//	zkid commitments      -tree tree.json [-add C]
+//	zkid prove            -credential credential.json -issuers issuers.json -threshold 18 -out proof.bin [-format json] [-commitments tree.json] [-scope 42]
+//	zkid prove            -credential credential.json -issuers issuers.json -threshold 18 -out proof.bin -commitments tree.json -scope 42 -period 1h -message-index 0 -message-limit 10
+//	zkid verify           -policy 1 -version 1 -proof proof.bin -issuers issuers.json -threshold 18 [-commitments tree.json] [-scope 42 -nullifiers spent.log]
+//	zkid verify           -policy 21 -version 1 -proof proof.bin -issuers issuers.json -threshold 18 -commitments tree.json -scope 42 -nullifiers spent.log -period 1h -message-limit 10
+//	zkid export-solidity  -policy 1 -out Verifier.sol
+//	zkid export-proof     -proof proof.bin [-format hex -compressed]
+//	zkid calldata         -proof proof.bin [-compressed] [-sol artifacts/policy-1/v1/Verifier.sol]
+//	zkid calldata         -decode 0x... -sol artifacts/policy-1/v1/Verifier.sol
+//	zkid export-snarkjs   -proof proof.bin -dir snarkjs/
+//	zkid verify-snarkjs   -policy 1 -version 1 -dir snarkjs/ -issuers issuers.json -threshold 18 [-vk snarkjs/verification_key.json]
+//	zkid inspect          [-policy 1]
+//
+// Every flag can also be supplied through -input, a JSON object whose keys
+// are flag names; flags given on the command line take precedence.
 package main
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"log"
-
-	setup_keys "github.com/kanthub/zkid-zkp/keys"
-	proof_age "github.com/kanthub/zkid-zkp/proof"
-	verify_age "github.com/kanthub/zkid-zkp/verifier_mock"
+	"os"
+	"sort"
+	"strings"
 )
 
-func main() {
-	// 1) Generate zk-SNARK key pair (ProvingKey + VerifyingKey), save pk to a local file, then generate a Solidity contract using vk
-	_, vk := setup_keys.GenerateKeys()
+// command is one subcommand of the CLI.
+type command struct {
+	summary string
+	run     func(ctx context.Context, args []string) error
+}
 
-	// 2) Generate a zk-SNARK proof, and save the proof to a local file
-	did := proof_age.ComputeLocalDID("Alice", "Wonderland", "123 Fantasy Rd", 28, 123456789, []byte{1, 2, 3, 4})
-	log.Printf("======Computed DID: %s ======", did.String())
+var commands = map[string]command{
+	"setup":           {"compile a policy circuit and generate its keys", runSetup},
+	"issuer-keygen":   {"generate an issuer signing key", runIssuerKeygen},
+	"issuers":         {"build or update the trusted issuer set and print its root", runIssuers},
+	"did":             {"compute the DID of a holder", runDID},
+	"commit":          {"compute the attribute commitment C and sign it", runCommit},
+	"commitments":     {"build or extend the issued commitment tree and print its root", runCommitments},
+	"prove":           {"generate a proof and its public inputs", runProve},
+	"verify":          {"verify a proof against its public inputs", runVerify},
+	"export-solidity": {"export the Solidity verifier of a policy", runExportSolidity},
+	"export-proof":    {"encode a proof bundle for the Solidity verifier", runExportProof},
+	"calldata":        {"ABI-encode a verifier call for a proof bundle", runCalldata},
+	"export-snarkjs":  {"write a proof bundle as snarkjs proof, public and key files", runExportSnarkJS},
+	"verify-snarkjs":  {"verify snarkjs proof and public files against the stored key", runVerifySnarkJS},
+	"inspect":         {"show registered policies and stored artifacts", runInspect},
+}
 
-	C := proof_age.ComputeCommitment(
-		1, 1, // policyID, version
-		"Alice", "Wonderland", "123 Fantasy Rd",
-		28, 123456789,
-		[]byte{1, 2, 3, 4},
-		did,
-	)
-	log.Printf("======Computed Commitment C: %s ======", C.String())
+func main() {
+	log.SetFlags(log.LstdFlags)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "zkid: unknown command %q\n\n", name)
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(context.Background(), os.Args[2:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return
+		}
+		log.Fatalf("zkid %s: %v", name, err)
+	}
+}
 
-	publicInputs, publicInputsStr, err := proof_age.GenerateProof(
-		1, 1, 18,
-		"Alice", "Wonderland", "123 Fantasy Rd",
-		28, 123456789,
-		[]byte{1, 2, 3, 4},
-		did, C,
-	)
-	if err != nil {
-		log.Fatalf("Proof generation failed: %v", err)
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
-	log.Printf("======Public inputs for verification: %v ======", publicInputs)
-	log.Printf("======Public inputs (string) for verification: %v ======", publicInputsStr)
+	sort.Strings(names)
 
-	// 3) Simulate the on-chain verification process: the user provides (1) public inputs and (2) the proof
-	verify_age.VerifyProof(
-		1, 1, 18,
-		"Alice", "Wonderland", "123 Fantasy Rd",
-		28, 123456789,
-		[]byte{1, 2, 3, 4},
-		did, C, vk,
-	)
+	var b strings.Builder
+	b.WriteString("usage: zkid <command> [flags]\n\ncommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(&b, "  %-16s %s\n", name, commands[name].summary)
+	}
+	b.WriteString("\nrun 'zkid <command> -h' for the flags of a command\n")
+	fmt.Fprint(os.Stderr, b.String())
 }
@@ -0,0 +1,159 @@
+// Canonical field-element encoding of credential attributes.
+//
+// Every value that enters the circuit is an element of the BN254 scalar field
+// F_r. This package is the single place where raw attributes are mapped to
+// those elements; the witness builder, the commitment and the verifier all go
+// through it so that independent clients derive byte-identical witnesses.
+//
+// Rules:
+//   - Strings (name, nation, address) must be non-empty, valid UTF-8 and at
+//     most MaxStringLen bytes. They are mapped with HashToField under a tag
+//     naming the attribute, so equal strings in different attributes differ.
+//   - AttrValue (biometric bytes) must be non-empty and at most
+//     MaxAttrValueLen bytes. It is mapped with HashToField under its own tag.
+//   - Age is kept as a raw integer in [0, MaxAge] so that predicates can
+//     compare it.
+//   - IdentityID is kept as a raw integer in [0, 2^63). Negative values are
+//     rejected instead of wrapping.
+//   - PolicyID and Version are non-negative integers.
+//   - Field elements supplied directly (DID, salt, C, thresholds) must be
+//     canonical, i.e. in [0, r). They are never reduced silently.
+package encoding
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"unicode/utf8"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	MaxAge          = 150
+	MaxStringLen    = 1024
+	MaxAttrValueLen = 1 << 16
+)
+
+// ErrOutOfDomain is returned when a value lies outside its attribute's domain.
+var ErrOutOfDomain = errors.New("value outside attribute domain")
+
+// Modulus returns r, the order of the BN254 scalar field.
+func Modulus() *big.Int {
+	return fr.Modulus()
+}
+
+// HashToField maps bytes to F_r under a domain tag:
+//
+//	Keccak256(len(tag) ‖ tag ‖ len(data) ‖ data) mod r
+//
+// with both lengths as big-endian uint64. The length prefixes make the
+// pre-image injective, and the tag keeps different uses apart.
+func HashToField(tag string, data []byte) *big.Int {
+	var n [8]byte
+	h := sha3.NewLegacyKeccak256()
+	binary.BigEndian.PutUint64(n[:], uint64(len(tag)))
+	h.Write(n[:])
+	h.Write([]byte(tag))
+	binary.BigEndian.PutUint64(n[:], uint64(len(data)))
+	h.Write(n[:])
+	h.Write(data)
+	x := new(big.Int).SetBytes(h.Sum(nil))
+	return x.Mod(x, fr.Modulus())
+}
+
+// DomainTag returns the field element that separates one hash domain from the
+// others when it is absorbed first, e.g. DomainTag("did").
+func DomainTag(name string) *big.Int {
+	return HashToField("zkid/domain", []byte(name))
+}
+
+// attributeTag is the HashToField tag of an attribute.
+func attributeTag(field string) string {
+	return "zkid/attr/" + field
+}
+
+// String encodes a textual attribute.
+func String(field, s string) (*big.Int, error) {
+	if s == "" || len(s) > MaxStringLen {
+		return nil, fmt.Errorf("%s: length must be in [1, %d]: %w", field, MaxStringLen, ErrOutOfDomain)
+	}
+	if !utf8.ValidString(s) {
+		return nil, fmt.Errorf("%s: not valid UTF-8: %w", field, ErrOutOfDomain)
+	}
+	return HashToField(attributeTag(field), []byte(s)), nil
+}
+
+// Bytes encodes a binary attribute such as biometric features.
+func Bytes(field string, b []byte) (*big.Int, error) {
+	if len(b) == 0 || len(b) > MaxAttrValueLen {
+		return nil, fmt.Errorf("%s: length must be in [1, %d]: %w", field, MaxAttrValueLen, ErrOutOfDomain)
+	}
+	return HashToField(attributeTag(field), b), nil
+}
+
+// Age encodes an age in years.
+func Age(age int64) (*big.Int, error) {
+	if age < 0 || age > MaxAge {
+		return nil, fmt.Errorf("age %d not in [0, %d]: %w", age, MaxAge, ErrOutOfDomain)
+	}
+	return big.NewInt(age), nil
+}
+
+// Uint encodes a non-negative integer such as an identity number, PolicyID or Version.
+func Uint(field string, v int64) (*big.Int, error) {
+	if v < 0 {
+		return nil, fmt.Errorf("%s %d is negative: %w", field, v, ErrOutOfDomain)
+	}
+	return big.NewInt(v), nil
+}
+
+// Element checks that x is a canonical field element and returns a copy.
+func Element(field string, x *big.Int) (*big.Int, error) {
+	if x == nil {
+		return nil, fmt.Errorf("%s is missing: %w", field, ErrOutOfDomain)
+	}
+	if x.Sign() < 0 || x.Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("%s is not in [0, r): %w", field, ErrOutOfDomain)
+	}
+	return new(big.Int).Set(x), nil
+}
+
+// Attributes holds the field encodings of the private attributes, exactly as
+// they are assigned to the circuit.
+type Attributes struct {
+	Name, Nation, Address, Age, IdentityID, AttrValue *big.Int
+}
+
+// EncodeAttributes applies the rules above to a full attribute set.
+func EncodeAttributes(
+	name, nation, address string,
+	age, identityID int64,
+	attrValue []byte,
+) (Attributes, error) {
+	var (
+		a   Attributes
+		err error
+	)
+	if a.Name, err = String("name", name); err != nil {
+		return Attributes{}, err
+	}
+	if a.Nation, err = String("nation", nation); err != nil {
+		return Attributes{}, err
+	}
+	if a.Address, err = String("address", address); err != nil {
+		return Attributes{}, err
+	}
+	if a.Age, err = Age(age); err != nil {
+		return Attributes{}, err
+	}
+	if a.IdentityID, err = Uint("identity_id", identityID); err != nil {
+		return Attributes{}, err
+	}
+	if a.AttrValue, err = Bytes("attr_value", attrValue); err != nil {
+		return Attributes{}, err
+	}
+	return a, nil
+}
@@ -0,0 +1,36 @@
+// Crash-safe replacement of small files.
+//
+// Verifiers load issuer sets and commitment trees from files that admins
+// rewrite in place. WriteFile writes and syncs the new content to a
+// temporary file in the same directory and renames it over the target, so
+// a crash leaves either the old or the new file, never a truncated one.
+package atomicfile
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFile is os.WriteFile with an atomic replace of path.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name()) // no-op after a successful rename
+	defer f.Close()
+
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Chmod(perm); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
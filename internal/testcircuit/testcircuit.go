@@ -0,0 +1,89 @@
+// Small Groth16 fixture for the proof encoders.
+//
+// The bundle, snarkjs and solidity packages only move proofs, public inputs
+// and verifying keys between formats; they do not care which statement is
+// proven. Their tests share this cubic circuit, whose setup takes
+// milliseconds, instead of running the setup of a full policy circuit.
+package testcircuit
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/frontend"
+	"github.com/consensys/gnark/frontend/cs/r1cs"
+)
+
+// Cubic has two public inputs: Y = X³ + X + 5 and Z = X·Y.
+type Cubic struct {
+	X frontend.Variable
+	Y frontend.Variable `gnark:",public"`
+	Z frontend.Variable `gnark:",public"`
+}
+
+func (c *Cubic) Define(api frontend.API) error {
+	x3 := api.Mul(c.X, c.X, c.X)
+	api.AssertIsEqual(c.Y, api.Add(x3, c.X, 5))
+	api.AssertIsEqual(c.Z, api.Mul(c.X, c.Y))
+	return nil
+}
+
+// Fixture is a valid proof of Cubic for X = 3 with its verifying key.
+type Fixture struct {
+	VK     groth16.VerifyingKey
+	Proof  groth16.Proof
+	Public []*big.Int // Y, Z in circuit order
+}
+
+// New runs a fresh setup of Cubic and proves X = 3. Independent calls
+// return independent keys.
+func New(t testing.TB) *Fixture {
+	t.Helper()
+	ccs, err := frontend.Compile(ecc.BN254.ScalarField(), r1cs.NewBuilder, &Cubic{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk, vk, err := groth16.Setup(ccs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w, err := frontend.NewWitness(&Cubic{X: 3, Y: 35, Z: 105}, ecc.BN254.ScalarField())
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := groth16.Prove(ccs, pk, w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	public, err := w.Public()
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := &Fixture{VK: vk, Proof: proof}
+	for _, x := range public.Vector().(fr.Vector) {
+		f.Public = append(f.Public, x.BigInt(new(big.Int)))
+	}
+	return f
+}
+
+// Verify runs groth16.Verify with the given public inputs. They are
+// assigned as gnark does for *big.Int, i.e. reduced mod r.
+func Verify(vk groth16.VerifyingKey, proof groth16.Proof, public []*big.Int) error {
+	w, err := witness.New(ecc.BN254.ScalarField())
+	if err != nil {
+		return err
+	}
+	values := make(chan any, len(public))
+	for _, x := range public {
+		values <- x
+	}
+	close(values)
+	if err := w.Fill(len(public), 0, values); err != nil {
+		return err
+	}
+	return groth16.Verify(proof, vk, w)
+}
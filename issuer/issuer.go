@@ -0,0 +1,186 @@
+// Issuer keys and commitment signatures.
+//
+// The issuer (the KYC oracle) vets a holder's attributes and signs the
+// resulting commitment C with EdDSA over the twisted Edwards curve embedded in
+// BN254 (its base field is the circuit field F_r), hashing with MiMC. The
+// circuit verifies that signature against a private issuer key that it
+// proves to be in the trusted set (see Set), so only commitments a trusted
+// oracle has signed can be proven; a holder computing their own C from
+// invented attributes has no valid signature for it.
+//
+// Signatures are over C as one field element, 32 bytes big-endian, the same
+// encoding the circuit hashes.
+package issuer
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	frhashmimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/consensys/gnark-crypto/ecc/bn254/twistededwards/eddsa"
+)
+
+// ErrBadSignature is returned when a signature does not verify.
+var ErrBadSignature = errors.New("bad issuer signature")
+
+// SignatureSize is the size of an encoded signature: R compressed ‖ S.
+const SignatureSize = 2 * fr.Bytes
+
+type (
+	// PrivateKey is an issuer signing key.
+	PrivateKey = eddsa.PrivateKey
+	// PublicKey is an issuer verification key, a point (X, Y) with
+	// coordinates in F_r.
+	PublicKey = eddsa.PublicKey
+)
+
+// GenerateKey draws a fresh issuer key pair from crypto/rand.
+func GenerateKey() (*PrivateKey, error) {
+	key, err := eddsa.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate issuer key: %w", err)
+	}
+	return key, nil
+}
+
+// message encodes C as the signed message.
+func message(C *big.Int) ([]byte, error) {
+	if C == nil || C.Sign() < 0 || C.Cmp(fr.Modulus()) >= 0 {
+		return nil, fmt.Errorf("commitment is not in [0, r)")
+	}
+	return C.FillBytes(make([]byte, fr.Bytes)), nil
+}
+
+// Sign signs the commitment C.
+func Sign(key *PrivateKey, C *big.Int) ([]byte, error) {
+	msg, err := message(C)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := key.Sign(msg, frhashmimc.NewMiMC())
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign commitment: %w", err)
+	}
+	return sig, nil
+}
+
+// Verify checks the issuer signature on C off-circuit, with the same
+// equation the circuit enforces.
+func Verify(pub *PublicKey, C *big.Int, sig []byte) error {
+	msg, err := message(C)
+	if err != nil {
+		return err
+	}
+	ok, err := pub.Verify(sig, msg, frhashmimc.NewMiMC())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	if !ok {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Point returns the coordinates of pub as field elements.
+func Point(pub *PublicKey) (x, y *big.Int) {
+	return pub.A.X.BigInt(new(big.Int)), pub.A.Y.BigInt(new(big.Int))
+}
+
+// PublicKeyFromPoint builds a public key from its coordinates and checks that
+// the point is on the curve.
+func PublicKeyFromPoint(x, y *big.Int) (*PublicKey, error) {
+	if x == nil || y == nil || x.Cmp(fr.Modulus()) >= 0 || y.Cmp(fr.Modulus()) >= 0 || x.Sign() < 0 || y.Sign() < 0 {
+		return nil, fmt.Errorf("issuer key coordinates are not in [0, r)")
+	}
+	var pub PublicKey
+	pub.A.X.SetBigInt(x)
+	pub.A.Y.SetBigInt(y)
+	if !pub.A.IsOnCurve() {
+		return nil, fmt.Errorf("issuer key is not on the curve")
+	}
+	return &pub, nil
+}
+
+// SignatureParts splits an encoded signature into R = (x, y) and S, as they
+// are assigned to the circuit.
+func SignatureParts(sig []byte) (rx, ry, s *big.Int, err error) {
+	var parsed eddsa.Signature
+	if _, err := parsed.SetBytes(sig); err != nil {
+		return nil, nil, nil, fmt.Errorf("%w: %v", ErrBadSignature, err)
+	}
+	return parsed.R.X.BigInt(new(big.Int)),
+		parsed.R.Y.BigInt(new(big.Int)),
+		new(big.Int).SetBytes(parsed.S[:]),
+		nil
+}
+
+// ParsePublicKey decodes a compressed public key (32 bytes, hex).
+func ParsePublicKey(s string) (*PublicKey, error) {
+	raw, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(s), "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode issuer key: %w", err)
+	}
+	var pub PublicKey
+	if n, err := pub.SetBytes(raw); err != nil || n != len(raw) {
+		return nil, fmt.Errorf("invalid issuer key")
+	}
+	return &pub, nil
+}
+
+// FormatPublicKey encodes pub compressed, as hex.
+func FormatPublicKey(pub *PublicKey) string {
+	return hex.EncodeToString(pub.Bytes())
+}
+
+// SaveKey writes the private key as hex to path, readable by the owner only.
+func SaveKey(path string, key *PrivateKey) error {
+	data := hex.EncodeToString(key.Bytes())
+	if err := os.WriteFile(path, []byte(data+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadKey reads a private key written by SaveKey.
+func LoadKey(path string) (*PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	var key PrivateKey
+	if n, err := key.SetBytes(raw); err != nil || n != len(raw) {
+		return nil, fmt.Errorf("%s: invalid issuer key", path)
+	}
+	return &key, nil
+}
+
+// SavePublicKey writes the compressed public key as hex to path.
+func SavePublicKey(path string, pub *PublicKey) error {
+	if err := os.WriteFile(path, []byte(FormatPublicKey(pub)+"\n"), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadPublicKey reads a public key written by SavePublicKey.
+func LoadPublicKey(path string) (*PublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	pub, err := ParsePublicKey(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return pub, nil
+}
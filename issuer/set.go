@@ -0,0 +1,231 @@
+// Trusted issuer sets.
+//
+// The circuit keeps the issuer key private and proves that it is a leaf of a
+// public Merkle root (see circuits.VerifyIssuer). A Set is that tree: each
+// issuer occupies one slot, and its leaf is
+//
+//	MiMC(circuits.IssuerDomain, X, Y)
+//
+// The depth is fixed by the circuit, so issuers can be added and removed
+// without regenerating keys; only the root changes. Verifiers accept the
+// roots they trust, holders prove against the root of the set their
+// verifier uses.
+package issuer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/internal/atomicfile"
+	"github.com/kanthub/zkid-zkp/merkle"
+)
+
+var (
+	// ErrNotInSet is returned for an issuer key that is not in the set.
+	ErrNotInSet = errors.New("issuer not in trusted set")
+	// ErrSetFull is returned when every slot of the set is taken.
+	ErrSetFull = errors.New("trusted issuer set is full")
+)
+
+// Leaf returns the tree leaf of an issuer key.
+func Leaf(pub *PublicKey) *big.Int {
+	x, y := Point(pub)
+	return merkle.Hash(circuits.IssuerDomain, x, y)
+}
+
+// Set is a trusted issuer set of depth circuits.IssuerTreeDepth.
+type Set struct {
+	slots []*PublicKey // nil for a free slot
+	tree  *merkle.Tree
+}
+
+// setJSON is the file form of a Set: one compressed hex key per slot, "" for
+// a free slot. Slots are kept so that removing an issuer does not move the
+// others.
+type setJSON struct {
+	Depth   int      `json:"depth"`
+	Issuers []string `json:"issuers"`
+}
+
+// NewSet returns a set holding keys in order.
+func NewSet(keys ...*PublicKey) (*Set, error) {
+	tree, err := merkle.New(circuits.IssuerTreeDepth)
+	if err != nil {
+		return nil, err
+	}
+	s := &Set{tree: tree}
+	for _, k := range keys {
+		if _, err := s.Add(k); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// index returns the slot of pub, or -1.
+func (s *Set) index(pub *PublicKey) int {
+	for i, k := range s.slots {
+		if k != nil && k.Equal(pub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Contains reports whether pub is in the set.
+func (s *Set) Contains(pub *PublicKey) bool {
+	return s.index(pub) >= 0
+}
+
+// Add puts pub into the first free slot and returns that slot.
+func (s *Set) Add(pub *PublicKey) (int, error) {
+	if pub == nil || !pub.A.IsOnCurve() {
+		return 0, fmt.Errorf("invalid issuer key")
+	}
+	if s.Contains(pub) {
+		return 0, fmt.Errorf("issuer %s is already in the set", FormatPublicKey(pub))
+	}
+	slot := len(s.slots)
+	for i, k := range s.slots {
+		if k == nil {
+			slot = i
+			break
+		}
+	}
+	if uint64(slot) >= s.tree.Size() {
+		return 0, ErrSetFull
+	}
+	if err := s.tree.Set(uint64(slot), Leaf(pub)); err != nil {
+		return 0, err
+	}
+	if slot == len(s.slots) {
+		s.slots = append(s.slots, nil)
+	}
+	key := *pub
+	s.slots[slot] = &key
+	return slot, nil
+}
+
+// Remove frees the slot of pub.
+func (s *Set) Remove(pub *PublicKey) error {
+	i := s.index(pub)
+	if i < 0 {
+		return ErrNotInSet
+	}
+	if err := s.tree.Set(uint64(i), big.NewInt(0)); err != nil {
+		return err
+	}
+	s.slots[i] = nil
+	for len(s.slots) > 0 && s.slots[len(s.slots)-1] == nil {
+		s.slots = s.slots[:len(s.slots)-1]
+	}
+	return nil
+}
+
+// Keys returns the issuers of the set in slot order.
+func (s *Set) Keys() []*PublicKey {
+	var out []*PublicKey
+	for _, k := range s.slots {
+		if k != nil {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// Root returns the Merkle root, the public input of the circuit.
+func (s *Set) Root() *big.Int {
+	return s.tree.Root()
+}
+
+// Path returns the slot of pub and the siblings of its leaf, as assigned to
+// IssuerIndex and IssuerPath.
+func (s *Set) Path(pub *PublicKey) (uint64, []*big.Int, error) {
+	i := s.index(pub)
+	if i < 0 {
+		return 0, nil, fmt.Errorf("%s: %w", FormatPublicKey(pub), ErrNotInSet)
+	}
+	path, err := s.tree.Path(uint64(i))
+	if err != nil {
+		return 0, nil, err
+	}
+	return uint64(i), path, nil
+}
+
+// MarshalJSON encodes the set slot by slot.
+func (s *Set) MarshalJSON() ([]byte, error) {
+	raw := setJSON{Depth: s.tree.Depth(), Issuers: make([]string, len(s.slots))}
+	for i, k := range s.slots {
+		if k != nil {
+			raw.Issuers[i] = FormatPublicKey(k)
+		}
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a set and rebuilds its tree. The depth must be the
+// one of the circuit.
+func (s *Set) UnmarshalJSON(data []byte) error {
+	var raw setJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if raw.Depth != circuits.IssuerTreeDepth {
+		return fmt.Errorf("issuer set depth %d, circuit has %d", raw.Depth, circuits.IssuerTreeDepth)
+	}
+	out, err := NewSet()
+	if err != nil {
+		return err
+	}
+	for i, h := range raw.Issuers {
+		if h == "" {
+			out.slots = append(out.slots, nil)
+			continue
+		}
+		pub, err := ParsePublicKey(h)
+		if err != nil {
+			return fmt.Errorf("issuer %d: %w", i, err)
+		}
+		if out.Contains(pub) {
+			return fmt.Errorf("issuer %d: duplicate key", i)
+		}
+		if uint64(i) >= out.tree.Size() {
+			return ErrSetFull
+		}
+		if err := out.tree.Set(uint64(i), Leaf(pub)); err != nil {
+			return err
+		}
+		out.slots = append(out.slots, pub)
+	}
+	*s = *out
+	return nil
+}
+
+// SaveSet writes the set as indented JSON, replacing path atomically.
+func SaveSet(path string, s *Set) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSet reads a set written by SaveSet.
+func LoadSet(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var s Set
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &s, nil
+}
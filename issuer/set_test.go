@@ -0,0 +1,45 @@
+package issuer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSaveSet checks that SaveSet replaces an existing set file and leaves
+// no temporary file behind.
+func TestSaveSet(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "issuers.json")
+	set, err := NewSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		key, err := GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := set.Add(&key.PublicKey); err != nil {
+			t.Fatal(err)
+		}
+		if err := SaveSet(path, set); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := LoadSet(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Keys()) != 2 || loaded.Root().Cmp(set.Root()) != 0 {
+		t.Fatalf("loaded %d keys, root %s; want 2, %s", len(loaded.Keys()), loaded.Root(), set.Root())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d files in the directory, want 1", len(entries))
+	}
+}
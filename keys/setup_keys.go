@@ -2,83 +2,74 @@
 package setup_keys
 
 import (
-	"log"
+	"encoding/json"
+	"fmt"
 	"os"
 
-	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark/backend/groth16"
-	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/frontend/cs/r1cs"
+	"github.com/consensys/gnark/constraint"
 
 	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/snarkjs"
 )
 
-func GenerateKeys() (groth16.ProvingKey, groth16.VerifyingKey) {
-	log.Println("Step 1: Compiling circuit...")
-
-	var circuit circuits.Circuit
-	field := fr.Modulus()
-
-	cs, err := frontend.Compile(field, r1cs.NewBuilder, &circuit)
+// Setup compiles the policy circuit and runs the Groth16 setup in memory.
+func Setup(policy circuits.Policy) (constraint.ConstraintSystem, groth16.ProvingKey, groth16.VerifyingKey, error) {
+	cs, err := circuits.Compile(policy)
 	if err != nil {
-		log.Fatalf("Circuit compilation failed: %v", err)
+		return nil, nil, nil, err
 	}
-	log.Println("Circuit compiled successfully")
-
-	log.Println("Step 2: Running Groth16 Setup...")
 	pk, vk, err := groth16.Setup(cs)
 	if err != nil {
-		log.Fatalf("Setup failed: %v", err)
+		return nil, nil, nil, fmt.Errorf("setup failed: %w", err)
 	}
-	log.Println("Setup completed")
+	return cs, pk, vk, nil
+}
 
-	// ----------------------------------------------------------------------
-	// 1) Save the ProvingKey (this is allowed)
-	// ----------------------------------------------------------------------
-	pkFile, err := os.Create("age_pk.bin")
+// LoadVerifyingKey reads a BN254 VerifyingKey from a file outside the
+// artifact store, such as a copy of its verifying.key. Keys are written only
+// through store.Store, which records their hash in the manifest.
+func LoadVerifyingKey(path string) (groth16.VerifyingKey, error) {
+	fvk, err := os.Open(path)
 	if err != nil {
-		log.Fatalf("Failed to create pk file: %v", err)
+		return nil, fmt.Errorf("failed to open vk file: %w", err)
 	}
-	defer pkFile.Close()
+	defer fvk.Close()
 
-	if _, err := pk.WriteTo(pkFile); err != nil {
-		log.Fatalf("Failed to write pk file: %v", err)
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if _, err := vk.ReadFrom(fvk); err != nil {
+		return nil, fmt.Errorf("failed to read vk: %w", err)
 	}
-	log.Println("Successfully saved age_pk.bin")
-
-	// ----------------------------------------------------------------------
-	// 2) Cannot serialize the VerifyingKey anymore!
-	//    The official library has removed VK's WriteTo / ReadFrom / encoding capability
-	//    The VerifyingKey must be used directly to generate the Solidity verifier
-	// ----------------------------------------------------------------------
-
-	// vkFile, err := os.Create("age_vk.bin")
-	// if err != nil {
-	// 	log.Fatalf("Failed to create vk file: %v", err)
-	// }
-	// defer vkFile.Close()
+	return vk, nil
+}
 
-	// if _, err := vk.WriteTo(vkFile); err != nil {
-	// 	log.Fatalf("Failed to write vk file: %v", err)
-	// }
-	// log.Println("Successfully saved age_vk.bin")
-	log.Println("⚠ Skipped saving age_vk.bin (unsupported by modern gnark)")
+// ExportSnarkJS writes vk as a snarkjs verification_key.json to path.
+func ExportSnarkJS(path string, vk groth16.VerifyingKey) error {
+	key, err := snarkjs.ExportVerifyingKey(vk)
+	if err != nil {
+		return fmt.Errorf("failed to export snarkjs verifying key: %w", err)
+	}
+	data, err := json.MarshalIndent(key, "", " ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
 
-	// ----------------------------------------------------------------------
-	// 3) Export the Solidity verifier contract directly
-	// ----------------------------------------------------------------------
-	verifierFile, err := os.Create("AgeVerifier.sol")
+// ExportSolidity writes the Solidity verifier contract for vk to path.
+func ExportSolidity(path string, vk groth16.VerifyingKey) error {
+	verifierFile, err := os.Create(path)
 	if err != nil {
-		log.Fatalf("Failed to create AgeVerifier.sol: %v", err)
+		return fmt.Errorf("failed to create %s: %w", path, err)
 	}
 	defer verifierFile.Close()
 
 	if err := vk.ExportSolidity(verifierFile); err != nil {
-		log.Fatalf("Failed to export solidity verifier: %v", err)
+		return fmt.Errorf("failed to export solidity verifier: %w", err)
 	}
-
-	log.Println("Successfully exported AgeVerifier.sol")
-	log.Println("🔵 Groth16 Key Generation Finished")
-
-	return pk, vk
+	return verifierFile.Close()
 }
@@ -0,0 +1,280 @@
+// Fixed-depth MiMC Merkle trees, the out-of-circuit counterpart of
+// circuits.MerkleRoot.
+//
+// A tree of depth d has 2^d leaves, all 0 until set. An inner node is
+//
+//	MiMC(left, right)
+//
+// with gnark-crypto's MiMC over F_r, the same parameters as the
+// gnark/std/hash/mimc gadget. Leaves are field elements chosen by the caller,
+// who is responsible for domain-separating them from inner nodes.
+//
+// A path lists the sibling of each node from the leaf up to (excluding) the
+// root; bit i of the leaf index is 1 when the node at level i is a right
+// child. Nodes are stored sparsely, so deep trees with few leaves are cheap.
+//
+// Trees are incremental: Append fills the leaves left to right, so an
+// issuer appends each new commitment and publishes the tree (see Save), and
+// holders load it and fetch the path of their leaf.
+package merkle
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	frhashmimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+
+	"github.com/kanthub/zkid-zkp/internal/atomicfile"
+)
+
+// MaxDepth bounds the depth of a tree so that indices fit a uint64.
+const MaxDepth = 63
+
+var (
+	// ErrIndex is returned for a leaf index outside the tree.
+	ErrIndex = errors.New("leaf index out of range")
+	// ErrFull is returned when appending to a tree whose leaves are all used.
+	ErrFull = errors.New("merkle tree is full")
+	// ErrNotFound is returned when a leaf is not in the tree.
+	ErrNotFound = errors.New("leaf not in tree")
+)
+
+// Hash absorbs field elements into gnark-crypto's MiMC (bn254/fr) and returns
+// the digest. The inputs must be canonical, i.e. in [0, r).
+func Hash(inputs ...*big.Int) *big.Int {
+	h := frhashmimc.NewMiMC()
+	for _, x := range inputs {
+		var fe fr.Element
+		fe.SetBigInt(x)
+		h.Write(fe.Marshal())
+	}
+	return new(big.Int).SetBytes(h.Sum(nil))
+}
+
+// Tree is a sparse Merkle tree of fixed depth.
+type Tree struct {
+	depth int
+	// nodes[level][index], level 0 holds the leaves; absent nodes are
+	// empty subtrees and equal zeros[level]
+	nodes []map[uint64]*big.Int
+	zeros []*big.Int
+	// n is one past the highest leaf ever set, the next Append index
+	n uint64
+}
+
+// New returns an empty tree of the given depth.
+func New(depth int) (*Tree, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, fmt.Errorf("merkle depth must be in [1, %d], got %d", MaxDepth, depth)
+	}
+	t := &Tree{
+		depth: depth,
+		nodes: make([]map[uint64]*big.Int, depth+1),
+		zeros: make([]*big.Int, depth+1),
+	}
+	t.zeros[0] = big.NewInt(0)
+	for i := 1; i <= depth; i++ {
+		t.zeros[i] = Hash(t.zeros[i-1], t.zeros[i-1])
+	}
+	for i := range t.nodes {
+		t.nodes[i] = map[uint64]*big.Int{}
+	}
+	return t, nil
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// Size returns the number of leaves, 2^depth.
+func (t *Tree) Size() uint64 {
+	return 1 << t.depth
+}
+
+// node returns the node at (level, index), or the empty subtree.
+func (t *Tree) node(level int, index uint64) *big.Int {
+	if n, ok := t.nodes[level][index]; ok {
+		return n
+	}
+	return t.zeros[level]
+}
+
+// Len returns the number of leaves in use: one past the highest index that
+// was set, including leaves emptied since.
+func (t *Tree) Len() uint64 {
+	return t.n
+}
+
+// Leaf returns the leaf at index.
+func (t *Tree) Leaf(index uint64) (*big.Int, error) {
+	if index >= t.Size() {
+		return nil, ErrIndex
+	}
+	return t.node(0, index), nil
+}
+
+// Set replaces the leaf at index and updates the path to the root. Setting
+// a leaf to 0 empties it.
+func (t *Tree) Set(index uint64, leaf *big.Int) error {
+	if index >= t.Size() {
+		return ErrIndex
+	}
+	if leaf == nil || leaf.Sign() < 0 || leaf.Cmp(fr.Modulus()) >= 0 {
+		return fmt.Errorf("merkle leaf is not in [0, r)")
+	}
+	if index >= t.n {
+		t.n = index + 1
+	}
+	t.put(0, index, new(big.Int).Set(leaf))
+	for level := 1; level <= t.depth; level++ {
+		index >>= 1
+		t.put(level, index, Hash(t.node(level-1, 2*index), t.node(level-1, 2*index+1)))
+	}
+	return nil
+}
+
+// Append sets the next unused leaf and returns its index.
+func (t *Tree) Append(leaf *big.Int) (uint64, error) {
+	index := t.n
+	if index >= t.Size() {
+		return 0, ErrFull
+	}
+	if err := t.Set(index, leaf); err != nil {
+		return 0, err
+	}
+	return index, nil
+}
+
+// IndexOf returns the lowest index holding leaf. Empty leaves (0) are not
+// searched.
+func (t *Tree) IndexOf(leaf *big.Int) (uint64, error) {
+	found := false
+	var index uint64
+	for i, l := range t.nodes[0] {
+		if l.Cmp(leaf) == 0 && (!found || i < index) {
+			index, found = i, true
+		}
+	}
+	if !found {
+		return 0, ErrNotFound
+	}
+	return index, nil
+}
+
+// put stores a node, dropping it when it equals the empty subtree.
+func (t *Tree) put(level int, index uint64, n *big.Int) {
+	if n.Cmp(t.zeros[level]) == 0 {
+		delete(t.nodes[level], index)
+		return
+	}
+	t.nodes[level][index] = n
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() *big.Int {
+	return new(big.Int).Set(t.node(t.depth, 0))
+}
+
+// Path returns the siblings of the leaf at index, from the leaf level up.
+func (t *Tree) Path(index uint64) ([]*big.Int, error) {
+	if index >= t.Size() {
+		return nil, ErrIndex
+	}
+	path := make([]*big.Int, t.depth)
+	for level := 0; level < t.depth; level++ {
+		path[level] = new(big.Int).Set(t.node(level, index^1))
+		index >>= 1
+	}
+	return path, nil
+}
+
+// ComputeRoot folds a leaf and its path into the root they imply. It
+// mirrors circuits.MerkleRoot.
+func ComputeRoot(leaf *big.Int, index uint64, path []*big.Int) (*big.Int, error) {
+	if len(path) > MaxDepth || index>>len(path) != 0 {
+		return nil, ErrIndex
+	}
+	node := leaf
+	for _, sibling := range path {
+		if index&1 == 0 {
+			node = Hash(node, sibling)
+		} else {
+			node = Hash(sibling, node)
+		}
+		index >>= 1
+	}
+	return node, nil
+}
+
+// treeJSON is the file form of a Tree: the depth and the first Len leaves as
+// decimal strings, "0" for an empty leaf.
+type treeJSON struct {
+	Depth  int      `json:"depth"`
+	Leaves []string `json:"leaves"`
+}
+
+// MarshalJSON encodes the depth and the leaves; inner nodes are recomputed
+// on decoding.
+func (t *Tree) MarshalJSON() ([]byte, error) {
+	raw := treeJSON{Depth: t.depth, Leaves: make([]string, t.n)}
+	for i := range raw.Leaves {
+		raw.Leaves[i] = t.node(0, uint64(i)).String()
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a tree and rebuilds its inner nodes.
+func (t *Tree) UnmarshalJSON(data []byte) error {
+	var raw treeJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out, err := New(raw.Depth)
+	if err != nil {
+		return err
+	}
+	if uint64(len(raw.Leaves)) > out.Size() {
+		return ErrFull
+	}
+	for i, s := range raw.Leaves {
+		leaf, ok := new(big.Int).SetString(s, 10)
+		if !ok {
+			return fmt.Errorf("merkle leaf %d: invalid integer %q", i, s)
+		}
+		if err := out.Set(uint64(i), leaf); err != nil {
+			return fmt.Errorf("merkle leaf %d: %w", i, err)
+		}
+	}
+	*t = *out
+	return nil
+}
+
+// Save writes the tree as JSON to path, replacing it atomically.
+func Save(path string, t *Tree) error {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := atomicfile.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// Load reads a tree written by Save.
+func Load(path string) (*Tree, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	var t Tree
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &t, nil
+}
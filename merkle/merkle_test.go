@@ -0,0 +1,45 @@
+package merkle
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestSave checks that Save replaces an existing tree file and leaves no
+// temporary file behind.
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "commitments.json")
+	tree, err := New(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, leaf := range []int64{11, 12} {
+		if _, err := tree.Append(big.NewInt(leaf)); err != nil {
+			t.Fatal(err)
+		}
+		if err := Save(path, tree); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Len() != 2 || loaded.Root().Cmp(tree.Root()) != 0 {
+		t.Fatalf("loaded %d leaves, root %s; want 2, %s", loaded.Len(), loaded.Root(), tree.Root())
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("%d files in the directory, want 1", len(entries))
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o644 {
+		t.Fatalf("mode %v, %v; want 0644", info.Mode(), err)
+	}
+}
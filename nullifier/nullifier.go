@@ -0,0 +1,159 @@
+// Nullifier stores for verifiers.
+//
+// A proof made with a non-zero scope carries the holder's nullifier for that
+// scope (see circuits.DeriveNullifier). The same holder always produces the
+// same nullifier in one scope, so a verifier that records every nullifier
+// it accepts can refuse a second claim, while nullifiers of different scopes
+// cannot be linked to each other.
+//
+// Store is the interface a verifier needs; MemoryStore keeps nullifiers in
+// memory and FileStore also appends them to a log file that is replayed on
+// open, so they survive restarts.
+package nullifier
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrUsed is returned when a nullifier was already recorded in its scope.
+var ErrUsed = errors.New("nullifier already used")
+
+// Store records the nullifiers seen per scope. Implementations must be safe
+// for concurrent use; Add must be atomic so that two concurrent claims with
+// one nullifier cannot both succeed.
+type Store interface {
+	// Add records nullifier in scope, or returns ErrUsed if it is already
+	// recorded there.
+	Add(ctx context.Context, scope, nullifier *big.Int) error
+	// Contains reports whether nullifier is recorded in scope.
+	Contains(ctx context.Context, scope, nullifier *big.Int) (bool, error)
+}
+
+// key is the map key of a (scope, nullifier) pair.
+func key(scope, nullifier *big.Int) string {
+	return scope.Text(16) + " " + nullifier.Text(16)
+}
+
+// MemoryStore is a Store held in memory.
+type MemoryStore struct {
+	mu   sync.Mutex
+	seen map[string]struct{}
+}
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{seen: map[string]struct{}{}}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(ctx context.Context, scope, nullifier *big.Int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.add(key(scope, nullifier))
+}
+
+func (s *MemoryStore) add(k string) error {
+	if _, ok := s.seen[k]; ok {
+		return ErrUsed
+	}
+	s.seen[k] = struct{}{}
+	return nil
+}
+
+// Contains implements Store.
+func (s *MemoryStore) Contains(ctx context.Context, scope, nullifier *big.Int) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.seen[key(scope, nullifier)]
+	return ok, nil
+}
+
+// FileStore is a Store backed by an append-only file with one
+// "<scope> <nullifier>" line (hex) per recorded nullifier. Each Add is
+// fsynced before it returns. Only one process may open a file at a time.
+type FileStore struct {
+	mu  sync.Mutex
+	mem *MemoryStore
+	f   *os.File
+}
+
+// OpenFileStore opens or creates the log at path and loads its nullifiers.
+func OpenFileStore(path string) (*FileStore, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", path, err)
+	}
+	mem := NewMemoryStore()
+	sc := bufio.NewScanner(f)
+	for line := 1; sc.Scan(); line++ {
+		k := strings.TrimSpace(sc.Text())
+		if k == "" {
+			continue
+		}
+		fields := strings.Fields(k)
+		if len(fields) != 2 {
+			f.Close()
+			return nil, fmt.Errorf("%s:%d: malformed nullifier record", path, line)
+		}
+		scope, ok1 := new(big.Int).SetString(fields[0], 16)
+		nullifier, ok2 := new(big.Int).SetString(fields[1], 16)
+		if !ok1 || !ok2 {
+			f.Close()
+			return nil, fmt.Errorf("%s:%d: malformed nullifier record", path, line)
+		}
+		// duplicates are harmless, e.g. after a crash between write and reply
+		_ = mem.add(key(scope, nullifier))
+	}
+	if err := sc.Err(); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return &FileStore{mem: mem, f: f}, nil
+}
+
+// Add implements Store. The record is on disk when Add returns nil.
+func (s *FileStore) Add(ctx context.Context, scope, nullifier *big.Int) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := key(scope, nullifier)
+	if _, ok := s.mem.seen[k]; ok {
+		return ErrUsed
+	}
+	if _, err := s.f.WriteString(k + "\n"); err != nil {
+		return fmt.Errorf("failed to record nullifier: %w", err)
+	}
+	if err := s.f.Sync(); err != nil {
+		return fmt.Errorf("failed to record nullifier: %w", err)
+	}
+	s.mem.seen[k] = struct{}{}
+	return nil
+}
+
+// Contains implements Store.
+func (s *FileStore) Contains(ctx context.Context, scope, nullifier *big.Int) (bool, error) {
+	return s.mem.Contains(ctx, scope, nullifier)
+}
+
+// Close closes the log file.
+func (s *FileStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
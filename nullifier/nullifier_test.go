@@ -0,0 +1,136 @@
+package nullifier
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+// testStore runs the Store contract against s.
+func testStore(t *testing.T, s Store) {
+	t.Helper()
+	ctx := context.Background()
+	scope, other := big.NewInt(42), big.NewInt(43)
+	n := big.NewInt(1234)
+
+	if ok, err := s.Contains(ctx, scope, n); err != nil || ok {
+		t.Fatalf("empty store: Contains = %v, %v", ok, err)
+	}
+	if err := s.Add(ctx, scope, n); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(ctx, scope, n); !errors.Is(err, ErrUsed) {
+		t.Fatalf("double spend: got %v, want ErrUsed", err)
+	}
+	if ok, err := s.Contains(ctx, scope, n); err != nil || !ok {
+		t.Fatalf("Contains = %v, %v after Add", ok, err)
+	}
+	// the same nullifier is unrelated in another scope
+	if err := s.Add(ctx, other, n); err != nil {
+		t.Fatalf("other scope: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := s.Add(cancelled, scope, big.NewInt(1)); !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled Add: got %v", err)
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	testStore(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	s, err := OpenFileStore(filepath.Join(t.TempDir(), "spent.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	testStore(t, s)
+}
+
+// TestFileStoreReload checks that spent nullifiers survive a restart.
+func TestFileStoreReload(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spent.log")
+	scope, n := big.NewInt(42), big.NewInt(1234)
+
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add(ctx, scope, n); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if ok, err := s.Contains(ctx, scope, n); err != nil || !ok {
+		t.Fatalf("after reload: Contains = %v, %v", ok, err)
+	}
+	if err := s.Add(ctx, scope, n); !errors.Is(err, ErrUsed) {
+		t.Fatalf("double spend after reload: got %v, want ErrUsed", err)
+	}
+	if err := s.Add(ctx, scope, big.NewInt(5678)); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileStoreMalformed(t *testing.T) {
+	for _, content := range []string{"2a\n", "2a 4d2 7\n", "2a zz\n"} {
+		path := filepath.Join(t.TempDir(), "spent.log")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if s, err := OpenFileStore(path); err == nil {
+			s.Close()
+			t.Fatalf("%q accepted", content)
+		}
+	}
+}
+
+// TestStoreConcurrentClaims checks that of many concurrent claims with one
+// nullifier exactly one succeeds.
+func TestStoreConcurrentClaims(t *testing.T) {
+	s, err := OpenFileStore(filepath.Join(t.TempDir(), "spent.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	const claims = 16
+	var (
+		wg sync.WaitGroup
+		mu sync.Mutex
+		ok int
+	)
+	for i := 0; i < claims; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := s.Add(context.Background(), big.NewInt(42), big.NewInt(1234))
+			if err == nil {
+				mu.Lock()
+				ok++
+				mu.Unlock()
+			} else if !errors.Is(err, ErrUsed) {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if ok != 1 {
+		t.Fatalf("%d of %d concurrent claims succeeded", ok, claims)
+	}
+}
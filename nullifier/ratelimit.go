@@ -0,0 +1,123 @@
+// Rate limiting with nullifiers.
+//
+// A rate-limit proof (circuits.RateLimitCircuit) carries a public epoch, a
+// message index below a public limit and the holder's nullifier for that
+// epoch and index. Recording the nullifiers of each epoch in a Store, with
+// the epoch as scope, therefore lets through at most limit proofs per holder
+// and epoch, without telling holders apart.
+package nullifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/kanthub/zkid-zkp/encoding"
+	"github.com/kanthub/zkid-zkp/merkle"
+)
+
+var (
+	// ErrRateLimited is returned for a message index at or above the limit.
+	ErrRateLimited = errors.New("rate limit exceeded")
+	// ErrEpoch is returned for a proof made for an epoch that is not current.
+	ErrEpoch = errors.New("epoch is not current")
+)
+
+// epochDomain separates epochs from nullifiers and tree nodes.
+var epochDomain = encoding.DomainTag("epoch")
+
+// CheckPeriod returns an error unless period, the length of an epoch, is a
+// positive whole number of seconds.
+func CheckPeriod(period time.Duration) error {
+	if period < time.Second || period%time.Second != 0 {
+		return fmt.Errorf("epoch period %s is not a positive whole number of seconds", period)
+	}
+	return nil
+}
+
+// Epoch returns the epoch of t for a verifier with the given scope and
+// period: MiMC(epochDomain, scope, t / period). Holders and verifier compute
+// it independently; including the scope keeps two verifiers with the same
+// period from sharing epochs, and thereby nullifiers. The period must pass
+// CheckPeriod.
+func Epoch(scope *big.Int, period time.Duration, t time.Time) (*big.Int, error) {
+	if err := CheckPeriod(period); err != nil {
+		return nil, err
+	}
+	return epoch(scope, period, t), nil
+}
+
+func epoch(scope *big.Int, period time.Duration, t time.Time) *big.Int {
+	n := t.Unix() / int64(period/time.Second)
+	if n < 0 {
+		n = 0
+	}
+	return merkle.Hash(epochDomain, scope, big.NewInt(n))
+}
+
+// RateLimiter admits at most Limit messages per holder and epoch. It keeps
+// the nullifiers of each epoch in a Store, which retains past epochs; use a
+// fresh store (or FileStore log) now and then to bound its size.
+type RateLimiter struct {
+	scope  *big.Int
+	period time.Duration
+	limit  uint64
+	store  Store
+}
+
+// NewRateLimiter returns a RateLimiter of limit messages per period, whose
+// epochs are derived from scope. The period must pass CheckPeriod; limit
+// must fit circuits.MessageLimitBits.
+func NewRateLimiter(scope *big.Int, period time.Duration, limit uint64, store Store) (*RateLimiter, error) {
+	scope, err := encoding.Element("scope", scope)
+	if err != nil {
+		return nil, err
+	}
+	if err := CheckPeriod(period); err != nil {
+		return nil, err
+	}
+	if limit == 0 || limit >= 1<<32 {
+		return nil, fmt.Errorf("message limit %d out of range", limit)
+	}
+	if store == nil {
+		return nil, fmt.Errorf("no nullifier store")
+	}
+	return &RateLimiter{scope: scope, period: period, limit: limit, store: store}, nil
+}
+
+// Limit returns the number of messages per holder and epoch.
+func (r *RateLimiter) Limit() uint64 {
+	return r.limit
+}
+
+// Epoch returns the epoch of t.
+func (r *RateLimiter) Epoch(t time.Time) *big.Int {
+	return epoch(r.scope, r.period, t)
+}
+
+// Check checks the public epoch, message index and limit of a proof at time
+// now. The previous epoch is still accepted, so that a proof made just
+// before an epoch boundary does not fail.
+func (r *RateLimiter) Check(now time.Time, epoch, index, limit *big.Int) error {
+	if epoch == nil || index == nil || limit == nil {
+		return fmt.Errorf("missing rate-limit inputs")
+	}
+	if !limit.IsUint64() || limit.Uint64() != r.limit {
+		return fmt.Errorf("%w: proof assumes %s messages per epoch, verifier allows %d", ErrRateLimited, limit, r.limit)
+	}
+	if !index.IsUint64() || index.Uint64() >= r.limit {
+		return fmt.Errorf("%w: message index %s", ErrRateLimited, index)
+	}
+	if epoch.Cmp(r.Epoch(now)) != 0 && epoch.Cmp(r.Epoch(now.Add(-r.period))) != 0 {
+		return ErrEpoch
+	}
+	return nil
+}
+
+// Add records the nullifier of a verified proof in its epoch, or returns
+// ErrUsed if that message index of the holder was already spent.
+func (r *RateLimiter) Add(ctx context.Context, epoch, nullifier *big.Int) error {
+	return r.store.Add(ctx, epoch, nullifier)
+}
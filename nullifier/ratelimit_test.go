@@ -0,0 +1,134 @@
+package nullifier
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestRateLimiterEpochs(t *testing.T) {
+	r, err := NewRateLimiter(big.NewInt(42), time.Hour, 3, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 16, 12, 30, 0, 0, time.UTC)
+	index, limit := big.NewInt(0), big.NewInt(3)
+
+	if err := r.Check(now, r.Epoch(now), index, limit); err != nil {
+		t.Fatalf("current epoch: %v", err)
+	}
+	if err := r.Check(now, r.Epoch(now.Add(-time.Hour)), index, limit); err != nil {
+		t.Fatalf("previous epoch: %v", err)
+	}
+	if err := r.Check(now, r.Epoch(now.Add(-2*time.Hour)), index, limit); !errors.Is(err, ErrEpoch) {
+		t.Fatalf("two epochs ago: got %v, want ErrEpoch", err)
+	}
+	if err := r.Check(now, r.Epoch(now.Add(time.Hour)), index, limit); !errors.Is(err, ErrEpoch) {
+		t.Fatalf("next epoch: got %v, want ErrEpoch", err)
+	}
+	if r.Epoch(now).Cmp(r.Epoch(now.Add(29*time.Minute))) != 0 {
+		t.Fatal("epoch changes within its period")
+	}
+
+	other, err := NewRateLimiter(big.NewInt(43), time.Hour, 3, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other.Epoch(now).Cmp(r.Epoch(now)) == 0 {
+		t.Fatal("two scopes share an epoch")
+	}
+}
+
+func TestRateLimiterLimit(t *testing.T) {
+	ctx := context.Background()
+	r, err := NewRateLimiter(big.NewInt(42), time.Hour, 2, NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	epoch := r.Epoch(now)
+	limit := big.NewInt(2)
+
+	// a holder spends both message indices of the epoch
+	for i, nullifier := range []*big.Int{big.NewInt(100), big.NewInt(101)} {
+		if err := r.Check(now, epoch, big.NewInt(int64(i)), limit); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+		if err := r.Add(ctx, epoch, nullifier); err != nil {
+			t.Fatalf("message %d: %v", i, err)
+		}
+	}
+	// a third message needs an index at the limit
+	if err := r.Check(now, epoch, big.NewInt(2), limit); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("index at the limit: got %v, want ErrRateLimited", err)
+	}
+	// or a spent index, whose nullifier is recorded
+	if err := r.Add(ctx, epoch, big.NewInt(100)); !errors.Is(err, ErrUsed) {
+		t.Fatalf("spent index: got %v, want ErrUsed", err)
+	}
+	// or a proof for a higher limit than the verifier's
+	if err := r.Check(now, epoch, big.NewInt(2), big.NewInt(3)); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("higher limit: got %v, want ErrRateLimited", err)
+	}
+	// the same nullifier in the next epoch is a different record
+	if err := r.Add(ctx, r.Epoch(now.Add(time.Hour)), big.NewInt(100)); err != nil {
+		t.Fatalf("next epoch: %v", err)
+	}
+}
+
+// TestRateLimiterRestart checks that a limiter over a FileStore keeps the
+// spent nullifiers of an epoch across a restart.
+func TestRateLimiterRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "spent.log")
+	now := time.Now()
+
+	s, err := OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewRateLimiter(big.NewInt(42), time.Hour, 1, s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(ctx, r.Epoch(now), big.NewInt(100)); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+
+	s, err = OpenFileStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if r, err = NewRateLimiter(big.NewInt(42), time.Hour, 1, s); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Add(ctx, r.Epoch(now), big.NewInt(100)); !errors.Is(err, ErrUsed) {
+		t.Fatalf("after restart: got %v, want ErrUsed", err)
+	}
+}
+
+func TestNewRateLimiter(t *testing.T) {
+	store := NewMemoryStore()
+	for _, tc := range []struct {
+		name   string
+		period time.Duration
+		limit  uint64
+	}{
+		{"zero period", 0, 1},
+		{"sub-second period", 1500 * time.Millisecond, 1},
+		{"zero limit", time.Hour, 0},
+		{"limit beyond 32 bits", time.Hour, 1 << 32},
+	} {
+		if _, err := NewRateLimiter(big.NewInt(42), tc.period, tc.limit, store); err == nil {
+			t.Errorf("%s accepted", tc.name)
+		}
+	}
+	if _, err := NewRateLimiter(big.NewInt(42), time.Hour, 1, nil); err == nil {
+		t.Error("nil store accepted")
+	}
+}
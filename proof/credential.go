@@ -0,0 +1,397 @@
+// Credential file format.
+// A credential is what the issuer hands to the holder: the attributes, the
+// DID, the salt and the commitment C for one policy, with the issuer's
+// signature on C. It replaces the long
+// positional argument lists of NewAssignmentCircuit and GenerateProof, where
+// two swapped int64s compile and silently yield a wrong commitment.
+// The JSON layout is described by CredentialSchema.
+package proof_age
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/encoding"
+	"github.com/kanthub/zkid-zkp/issuer"
+	"github.com/kanthub/zkid-zkp/merkle"
+)
+
+// CredentialSchemaID is the value of the "schema" member of a credential.
+const CredentialSchemaID = "zkid/credential/v1"
+
+// CredentialSchema is the JSON Schema (draft 2020-12) of a credential file.
+//
+//go:embed credential.schema.json
+var CredentialSchema string
+
+// Credential holds the issued attributes of one holder for one policy.
+// The holder secret of a DIDv2 credential is not part of it; it is kept
+// apart (see SaveSecret) and supplied when building the witness.
+type Credential struct {
+	PolicyID int64
+	Version  int64
+
+	Name, Nation, Address string
+	Age, IdentityID       int64
+	AttrValue             []byte // biometric features, base64 in JSON
+
+	DIDVersion DIDVersion // DIDv1 or DIDv2
+	DID        *big.Int
+	Salt       *big.Int
+	C          *big.Int // commitment
+
+	// Issuer signature on C (see Sign). Optional in the file, required to
+	// prove.
+	IssuerKey *issuer.PublicKey // compressed, hex in JSON
+	Signature []byte            // hex in JSON
+}
+
+// credentialJSON is the wire form of Credential. Field elements are strings
+// because JSON numbers lose precision beyond 2^53 in most parsers.
+type credentialJSON struct {
+	Schema     string `json:"schema"`
+	PolicyID   int64  `json:"policy_id"`
+	Version    int64  `json:"version"`
+	Name       string `json:"name"`
+	Nation     string `json:"nation"`
+	Address    string `json:"address"`
+	Age        int64  `json:"age"`
+	IdentityID int64  `json:"identity_id"`
+	AttrValue  []byte `json:"attr_value"`
+	DIDVersion int    `json:"did_version"`
+	DID        string `json:"did"`
+	Salt       string `json:"salt"`
+	Commitment string `json:"commitment"`
+	IssuerKey  string `json:"issuer_key,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+}
+
+// NewCredential computes the commitment of a freshly issued credential on
+// the holder side. secret is required for DIDv2 and must be nil for DIDv1;
+// it is only used to check did and is not stored. An issuer, who must not
+// learn the secret, fills in a Credential and calls Commit instead.
+func NewCredential(
+	policy circuits.Policy,
+	name, nation, address string,
+	age, identityID int64,
+	attrValue []byte,
+	secret, did, salt *big.Int,
+) (*Credential, error) {
+	version := didVersionOf(secret)
+	expected, err := ComputeLocalDID(version, name, nation, address, age, identityID, attrValue, secret)
+	if err != nil {
+		return nil, err
+	}
+	if did == nil || did.Cmp(expected) != 0 {
+		return nil, ErrDIDMismatch
+	}
+	c := &Credential{
+		PolicyID:   policy.ID,
+		Version:    policy.Version,
+		Name:       name,
+		Nation:     nation,
+		Address:    address,
+		Age:        age,
+		IdentityID: identityID,
+		AttrValue:  attrValue,
+		DIDVersion: version,
+		DID:        did,
+		Salt:       salt,
+	}
+	if err := c.Commit(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Commit computes the commitment C of a credential whose policy, attributes,
+// DIDVersion, DID and salt are set. It is the issuer side of NewCredential
+// and takes no holder secret: a DIDv1 DID is re-derived from the
+// attributes, a DIDv2 DID is committed as the holder presents it. A wrong
+// DIDv2 DID cannot be detected here, but leaves the holder unable to prove.
+func (c *Credential) Commit() error {
+	attrs, err := encoding.EncodeAttributes(c.Name, c.Nation, c.Address, c.Age, c.IdentityID, c.AttrValue)
+	if err != nil {
+		return err
+	}
+	if _, err := encoding.Element("did", c.DID); err != nil {
+		return err
+	}
+	if err := checkSalt(c.Salt); err != nil {
+		return err
+	}
+	c.C = commitmentOf(big.NewInt(c.PolicyID), big.NewInt(c.Version), attrs, c.DID, c.Salt)
+	return c.Validate()
+}
+
+// Sign has the issuer sign the credential's commitment and records the
+// signature and the issuer key.
+func (c *Credential) Sign(key *issuer.PrivateKey) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	sig, err := issuer.Sign(key, c.C)
+	if err != nil {
+		return err
+	}
+	c.IssuerKey = &key.PublicKey
+	c.Signature = sig
+	return nil
+}
+
+// Validate checks the credential against the encoding rules and recomputes
+// its commitment. For DIDv1 the DID is re-derived as well; a DIDv2 DID can
+// only be checked with the secret, which Assignment does. An issuer
+// signature, if present, must verify.
+func (c *Credential) Validate() error {
+	policy, err := circuits.LookupPolicy(c.PolicyID)
+	if err != nil {
+		return err
+	}
+	if c.Version != policy.Version {
+		return fmt.Errorf("credential is for policy %d v%d, registered version is v%d",
+			c.PolicyID, c.Version, policy.Version)
+	}
+	attrs, err := encoding.EncodeAttributes(c.Name, c.Nation, c.Address, c.Age, c.IdentityID, c.AttrValue)
+	if err != nil {
+		return err
+	}
+	if _, err := encoding.Element("did", c.DID); err != nil {
+		return err
+	}
+	if _, err := encoding.Element("commitment", c.C); err != nil {
+		return err
+	}
+	if err := checkSalt(c.Salt); err != nil {
+		return err
+	}
+
+	switch c.DIDVersion {
+	case DIDv1:
+		if deriveDID(attrs, nil).Cmp(c.DID) != 0 {
+			return ErrDIDMismatch
+		}
+	case DIDv2:
+	default:
+		return fmt.Errorf("credential did_version must be %d or %d, got %d", DIDv1, DIDv2, c.DIDVersion)
+	}
+
+	if commitmentOf(big.NewInt(c.PolicyID), big.NewInt(c.Version), attrs, c.DID, c.Salt).Cmp(c.C) != 0 {
+		return fmt.Errorf("credential commitment does not match its attributes")
+	}
+
+	if (c.IssuerKey == nil) != (c.Signature == nil) {
+		return fmt.Errorf("credential needs both issuer_key and signature, or neither")
+	}
+	if c.IssuerKey != nil {
+		if err := issuer.Verify(c.IssuerKey, c.C, c.Signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ProofOptions are the inputs of a proof besides the credential. They are
+// named fields rather than parameters, so that two values of one type (the
+// threshold and the upper bound, the secret and the scope) cannot be
+// swapped without notice.
+type ProofOptions struct {
+	Issuers     *issuer.Set  // trusted set holding the credential's issuer; its root is public
+	Commitments *merkle.Tree // issued commitments, membership and rate-limit modes only
+
+	Secret     *big.Int // holder secret, DIDv2 credentials only
+	Threshold  *big.Int
+	UpperBound *big.Int // only used by range predicates, nil for 0
+
+	Scope   *big.Int // verifier scope of the nullifier, nil for none
+	Message *Message // epoch and message index, rate-limit-mode policies only
+}
+
+// Assignment is the witness builder for a credential: it returns the circuit
+// assignment proving the credential's policy predicate against the public
+// bounds of opts, with its issuer in opts.Issuers and the nullifier of
+// opts.Scope. opts.Secret is required for DIDv2 and must be nil for DIDv1.
+// PolicyAssignment turns it into the witness of the policy's mode.
+func (c *Credential) Assignment(opts ProofOptions) (*circuits.Circuit, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	if c.IssuerKey == nil {
+		return nil, ErrUnsigned
+	}
+	if didVersionOf(opts.Secret) != c.DIDVersion {
+		if opts.Secret == nil {
+			return nil, fmt.Errorf("DIDv2 credential needs the holder secret")
+		}
+		return nil, fmt.Errorf("DIDv1 credential takes no secret")
+	}
+	upperBound := opts.UpperBound
+	if upperBound == nil {
+		upperBound = big.NewInt(0)
+	}
+	assignment, err := NewAssignmentCircuit(
+		c.PolicyID, c.Version,
+		opts.Threshold, upperBound,
+		c.Name, c.Nation, c.Address,
+		c.Age, c.IdentityID,
+		c.AttrValue, opts.Secret,
+		c.DID, c.Salt, c.C,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := SetIssuer(assignment, opts.Issuers, c.IssuerKey, c.Signature); err != nil {
+		return nil, err
+	}
+	if err := SetScope(assignment, opts.Scope); err != nil {
+		return nil, err
+	}
+	return assignment, nil
+}
+
+// MarshalJSON encodes the credential in the CredentialSchema layout.
+func (c *Credential) MarshalJSON() ([]byte, error) {
+	if c.DID == nil || c.Salt == nil || c.C == nil {
+		return nil, fmt.Errorf("credential is missing did, salt or commitment")
+	}
+	raw := credentialJSON{
+		Schema:     CredentialSchemaID,
+		PolicyID:   c.PolicyID,
+		Version:    c.Version,
+		Name:       c.Name,
+		Nation:     c.Nation,
+		Address:    c.Address,
+		Age:        c.Age,
+		IdentityID: c.IdentityID,
+		AttrValue:  c.AttrValue,
+		DIDVersion: int(c.DIDVersion),
+		DID:        c.DID.String(),
+		Salt:       c.Salt.String(),
+		Commitment: c.C.String(),
+	}
+	if c.IssuerKey != nil {
+		raw.IssuerKey = issuer.FormatPublicKey(c.IssuerKey)
+		raw.Signature = hex.EncodeToString(c.Signature)
+	}
+	return json.Marshal(raw)
+}
+
+// UnmarshalJSON decodes a credential. Unknown members are rejected, so that
+// a misspelt key is not silently ignored.
+func (c *Credential) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var raw credentialJSON
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+	if raw.Schema != CredentialSchemaID {
+		return fmt.Errorf("credential schema %q, expected %q", raw.Schema, CredentialSchemaID)
+	}
+
+	out := Credential{
+		PolicyID:   raw.PolicyID,
+		Version:    raw.Version,
+		Name:       raw.Name,
+		Nation:     raw.Nation,
+		Address:    raw.Address,
+		Age:        raw.Age,
+		IdentityID: raw.IdentityID,
+		AttrValue:  raw.AttrValue,
+		DIDVersion: DIDVersion(raw.DIDVersion),
+	}
+	for _, f := range []struct {
+		name string
+		s    string
+		dst  **big.Int
+	}{
+		{"did", raw.DID, &out.DID},
+		{"salt", raw.Salt, &out.Salt},
+		{"commitment", raw.Commitment, &out.C},
+	} {
+		x, ok := ParseElement(f.s)
+		if !ok {
+			return fmt.Errorf("credential %s: invalid integer %q", f.name, f.s)
+		}
+		*f.dst = x
+	}
+	if raw.IssuerKey != "" {
+		pub, err := issuer.ParsePublicKey(raw.IssuerKey)
+		if err != nil {
+			return fmt.Errorf("credential issuer_key: %w", err)
+		}
+		out.IssuerKey = pub
+	}
+	if raw.Signature != "" {
+		sig, err := hex.DecodeString(raw.Signature)
+		if err != nil {
+			return fmt.Errorf("credential signature: %w", err)
+		}
+		out.Signature = sig
+	}
+	*c = out
+	return nil
+}
+
+// ParseElement reads a field element string as credential.schema.json
+// spells it: 0x-prefixed hex or plain decimal. Leading zeros are decimal,
+// and signs, other prefixes and digit separators are rejected, so every
+// conforming tool reads the same value. The range is not checked.
+func ParseElement(s string) (*big.Int, bool) {
+	digits, base := s, 10
+	if strings.HasPrefix(s, "0x") {
+		digits, base = s[2:], 16
+	}
+	if digits == "" || digits[0] == '+' || digits[0] == '-' {
+		return nil, false
+	}
+	return new(big.Int).SetString(digits, base)
+}
+
+// ParseCredential decodes and validates a credential.
+func ParseCredential(data []byte) (*Credential, error) {
+	var c Credential
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("failed to decode credential: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid credential: %w", err)
+	}
+	return &c, nil
+}
+
+// LoadCredential reads and validates a credential file.
+func LoadCredential(path string) (*Credential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	c, err := ParseCredential(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// SaveCredential writes the credential to path, readable by the owner only:
+// it contains the salt and the plain attributes.
+func SaveCredential(path string, c *Credential) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
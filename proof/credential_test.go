@@ -0,0 +1,32 @@
+package proof_age
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParseElement(t *testing.T) {
+	valid := []struct {
+		s    string
+		want int64
+	}{
+		{"0", 0},
+		{"123", 123},
+		{"0123", 123}, // decimal, not octal
+		{"0x7b", 123},
+		{"0x7B", 123},
+		{"0x007b", 123},
+	}
+	for _, tc := range valid {
+		got, ok := ParseElement(tc.s)
+		if !ok || got.Cmp(big.NewInt(tc.want)) != 0 {
+			t.Errorf("ParseElement(%q) = %v, %v; want %d", tc.s, got, ok, tc.want)
+		}
+	}
+
+	for _, s := range []string{"", "0x", "0X7b", "0b101", "0o17", "1_000", "0x_7b", "+1", "-1", "0x-1", " 1", "12a"} {
+		if got, ok := ParseElement(s); ok {
+			t.Errorf("ParseElement(%q) = %v, want rejected", s, got)
+		}
+	}
+}
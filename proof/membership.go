@@ -0,0 +1,71 @@
+// Membership mode witness: the holder proves that the private C is one of the
+// commitments the issuer has appended to its commitment tree.
+package proof_age
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/frontend"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/merkle"
+)
+
+// NewCommitmentTree returns an empty tree of issued commitments, of the depth
+// the membership circuits expect. The issuer appends the C of every
+// credential it signs and publishes the tree with merkle.Save.
+func NewCommitmentTree() (*merkle.Tree, error) {
+	return merkle.New(circuits.CommitmentTreeDepth)
+}
+
+// MembershipAssignment wraps a Circuit assignment for a membership-mode
+// policy: it looks C up in commitments and assigns its path, and the root of
+// commitments becomes the public CommitmentsRoot.
+func MembershipAssignment(assignment *circuits.Circuit, commitments *merkle.Tree) (*circuits.MembershipCircuit, error) {
+	if commitments == nil {
+		return nil, fmt.Errorf("membership and rate-limit modes need the commitment tree")
+	}
+	if commitments.Depth() != circuits.CommitmentTreeDepth {
+		return nil, fmt.Errorf("commitment tree depth %d, circuit has %d",
+			commitments.Depth(), circuits.CommitmentTreeDepth)
+	}
+	C, ok := assignment.C.(*big.Int)
+	if !ok {
+		return nil, fmt.Errorf("assignment has no commitment")
+	}
+	index, err := commitments.IndexOf(C)
+	if err != nil {
+		return nil, fmt.Errorf("commitment %s: %w", C, err)
+	}
+	path, err := commitments.Path(index)
+	if err != nil {
+		return nil, err
+	}
+
+	var sibling [circuits.CommitmentTreeDepth]frontend.Variable
+	for i := range sibling {
+		sibling[i] = path[i]
+	}
+	return circuits.NewMembershipCircuit(
+		assignment,
+		commitments.Root(),
+		new(big.Int).SetUint64(index),
+		sibling,
+	), nil
+}
+
+// PolicyAssignment returns the witness of the policy's circuit: assignment
+// itself in commitment mode, its MembershipAssignment in membership mode or
+// its RateLimitAssignment in rate-limit mode. commitments is not used in
+// commitment mode, msg only in rate-limit mode.
+func PolicyAssignment(policy circuits.Policy, assignment *circuits.Circuit, commitments *merkle.Tree, msg *Message) (frontend.Circuit, error) {
+	switch policy.Mode {
+	case circuits.ModeMembership:
+		return MembershipAssignment(assignment, commitments)
+	case circuits.ModeRateLimit:
+		return RateLimitAssignment(assignment, commitments, msg)
+	default:
+		return assignment, nil
+	}
+}
@@ -0,0 +1,44 @@
+// Scoped nullifiers on the holder side, see circuits.DeriveNullifier.
+package proof_age
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/encoding"
+	"github.com/kanthub/zkid-zkp/merkle"
+)
+
+// ComputeNullifier returns the nullifier of a holder in scope, as enforced by
+// the circuit: MiMC(NullifierDomain, DID, Secret, Scope), or 0 for a nil or
+// zero scope. secret is nil for DIDv1.
+func ComputeNullifier(did, secret, scope *big.Int) *big.Int {
+	if scope == nil || scope.Sign() == 0 {
+		return big.NewInt(0)
+	}
+	if secret == nil {
+		secret = big.NewInt(0)
+	}
+	return merkle.Hash(circuits.NullifierDomain, did, secret, scope)
+}
+
+// SetScope assigns the verifier's scope and the matching nullifier. A nil or
+// zero scope leaves both at 0, i.e. no nullifier.
+func SetScope(assignment *circuits.Circuit, scope *big.Int) error {
+	if scope == nil {
+		scope = big.NewInt(0)
+	}
+	scope, err := encoding.Element("scope", scope)
+	if err != nil {
+		return err
+	}
+	did, ok1 := assignment.DID.(*big.Int)
+	secret, ok2 := assignment.Secret.(*big.Int)
+	if !ok1 || !ok2 {
+		return fmt.Errorf("assignment has no DID")
+	}
+	assignment.Scope = scope
+	assignment.Nullifier = ComputeNullifier(did, secret, scope)
+	return nil
+}
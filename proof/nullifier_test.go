@@ -0,0 +1,23 @@
+package proof_age
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestComputeNullifierNoScope(t *testing.T) {
+	did, secret := big.NewInt(7), big.NewInt(9)
+	for _, scope := range []*big.Int{nil, big.NewInt(0)} {
+		if n := ComputeNullifier(did, secret, scope); n.Sign() != 0 {
+			t.Fatalf("scope %v: nullifier %s, want 0", scope, n)
+		}
+	}
+	a := ComputeNullifier(did, secret, big.NewInt(1))
+	b := ComputeNullifier(did, secret, big.NewInt(2))
+	if a.Sign() == 0 || a.Cmp(b) == 0 {
+		t.Fatal("scoped nullifiers are 0 or shared across scopes")
+	}
+	if ComputeNullifier(did, nil, big.NewInt(1)).Cmp(a) == 0 {
+		t.Fatal("nullifier does not depend on the secret")
+	}
+}
@@ -1,6 +1,6 @@
 // Terminal CLI: locally generate proof for the user.
-// The user privately holds the file proof_age.bin.
-// The user then brings proof_age.bin to the blockchain for verification.
+// The user privately holds the resulting proof bundle (see package bundle).
+// The user then brings the bundle to the verifier or the blockchain.
 
 // To generate a proof, the user needs:
 //  1. Private inputs + public inputs
@@ -9,121 +9,265 @@
 package proof_age
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
-	"os"
 
-	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/groth16"
-	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
 	"github.com/consensys/gnark/backend/witness"
+	"github.com/consensys/gnark/constraint"
 	"github.com/consensys/gnark/frontend"
-	"github.com/consensys/gnark/frontend/cs/r1cs"
 	"golang.org/x/crypto/sha3"
 
-	frhashmimc "github.com/consensys/gnark-crypto/ecc/bn254/fr/mimc"
+	"github.com/kanthub/zkid-zkp/bundle"
 	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/encoding"
+	"github.com/kanthub/zkid-zkp/issuer"
+	"github.com/kanthub/zkid-zkp/merkle"
+	"github.com/kanthub/zkid-zkp/store"
 )
 
+var (
+	// ErrDIDMismatch is returned when the supplied DID is not the one derived
+	// from the attributes (and secret).
+	ErrDIDMismatch = errors.New("did not match")
+
+	// ErrPredicateUnsatisfied is returned when the attributes do not satisfy
+	// the policy predicate, so no valid proof exists.
+	ErrPredicateUnsatisfied = errors.New("predicate not satisfied")
+
+	// ErrUnsigned is returned when proving a commitment that carries no
+	// issuer signature; the circuit has no witness for it.
+	ErrUnsigned = errors.New("commitment is not signed by an issuer")
+)
+
+// DIDVersion selects how ComputeLocalDID derives a DID.
+type DIDVersion int
+
+const (
+	// DIDLegacy is the original Keccak256(name‖nation‖address‖age‖id‖attr)
+	// concatenation without separators. It is not injective and the circuit
+	// cannot prove it; it only exists to reproduce DIDs issued before DIDv1.
+	DIDLegacy DIDVersion = 0
+
+	// DIDv1 = MiMC(DIDDomain, 1, encoded attributes), enforced by the circuit.
+	DIDv1 DIDVersion = circuits.DIDVersion1
+
+	// DIDv2 = MiMC(DIDDomain, 2, secret, encoded attributes), enforced by the
+	// circuit. Leaked attributes alone do not reveal it.
+	DIDv2 DIDVersion = circuits.DIDVersion2
+)
+
+// didVersionOf returns the circuit DID version implied by an optional secret.
+func didVersionOf(secret *big.Int) DIDVersion {
+	if secret == nil {
+		return DIDv1
+	}
+	return DIDv2
+}
+
+// deriveDID mirrors circuits.Circuit.DeriveDID. secret is nil for DIDv1.
+func deriveDID(a encoding.Attributes, secret *big.Int) *big.Int {
+	if secret == nil {
+		return merkle.Hash(
+			circuits.DIDDomain,
+			big.NewInt(circuits.DIDVersion1),
+			a.Name, a.Nation, a.Address, a.Age, a.IdentityID, a.AttrValue,
+		)
+	}
+	return merkle.Hash(
+		circuits.DIDDomain,
+		big.NewInt(circuits.DIDVersion2),
+		secret,
+		a.Name, a.Nation, a.Address, a.Age, a.IdentityID, a.AttrValue,
+	)
+}
+
+// legacyDID reproduces the pre-v1 scheme byte for byte.
+func legacyDID(
+	name, nation, address string,
+	age, identityID int64,
+	attrValue []byte,
+) *big.Int {
+	// Convert integers to bytes
+	ageBytes := big.NewInt(age).Bytes()
+	idBytes := big.NewInt(identityID).Bytes()
+
+	// Keccak256(name + nation + address + age + identityID + attrValue)
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write([]byte(name))
+	hasher.Write([]byte(nation))
+	hasher.Write([]byte(address))
+	hasher.Write(ageBytes)
+	hasher.Write(idBytes)
+	hasher.Write(attrValue)
+
+	return new(big.Int).SetBytes(hasher.Sum(nil))
+}
+
 // AssignmentCircuit is the witness constructor used on the user side.
 // It is responsible for converting all fields (string / number / bytes)
 // into field elements (big.Int) inside the circuit.
+// A nil secret selects an attribute-derived DIDv1, otherwise DIDv2.
 func NewAssignmentCircuit(
-	policyID, version, threshold int64,
+	policyID, version int64,
+	threshold, upperBound *big.Int,
 	name, nation, address string,
 	age, identityID int64,
 	attrValue []byte, // fingerprint features (bytes)
-	did, C *big.Int,
+	secret *big.Int, // holder secret, nil for DIDv1
+	did, salt, C *big.Int,
 ) (*circuits.Circuit, error) {
 
-	// 1. Helper: Keccak256(bytes) → *big.Int
-	toBigInt := func(data []byte) *big.Int {
-		h := sha3.NewLegacyKeccak256()
-		h.Write(data)
-		sum := h.Sum(nil)
-		return new(big.Int).SetBytes(sum)
+	// 1. Encode the attributes and public inputs (see package encoding for the rules)
+	attrs, err := encoding.EncodeAttributes(name, nation, address, age, identityID, attrValue)
+	if err != nil {
+		return nil, err
+	}
+	policyInt, err := encoding.Uint("policy_id", policyID)
+	if err != nil {
+		return nil, err
+	}
+	versionInt, err := encoding.Uint("version", version)
+	if err != nil {
+		return nil, err
+	}
+	if threshold, err = encoding.Element("threshold", threshold); err != nil {
+		return nil, err
+	}
+	if upperBound, err = encoding.Element("upper_bound", upperBound); err != nil {
+		return nil, err
+	}
+	if C, err = encoding.Element("commitment", C); err != nil {
+		return nil, err
+	}
+	if did, err = encoding.Element("did", did); err != nil {
+		return nil, err
+	}
+	secretInt := big.NewInt(0)
+	if secret != nil {
+		if err := checkSecret(secret); err != nil {
+			return nil, err
+		}
+		secretInt = secret
 	}
 
-	// 2. Hash string fields
-	nameInt := toBigInt([]byte(name))
-	nationInt := toBigInt([]byte(nation))
-	addressInt := toBigInt([]byte(address))
-
-	// 3. Process numeric fields
-	ageBytes := big.NewInt(age).Bytes()
-	ageInt := big.NewInt(age) // Do NOT hash for age; it's a raw value used for comparison
-
-	idBytes := big.NewInt(identityID).Bytes()
-	identityInt := toBigInt(idBytes)
-
-	// 4. AttrValue (fingerprint) raw bytes → hash
-	attrInt := toBigInt(attrValue)
-
-	// 5. DID: hash the concatenation of original fields
-	//    Keccak256(name + nation + address + age + identityID + attrValue)
-	didHasher := sha3.NewLegacyKeccak256()
-	didHasher.Write([]byte(name))
-	didHasher.Write([]byte(nation))
-	didHasher.Write([]byte(address))
-	didHasher.Write(ageBytes)
-	didHasher.Write(idBytes)
-	didHasher.Write(attrValue)
-	didSum := didHasher.Sum(nil)
-	didInt := new(big.Int).SetBytes(didSum)
-	log.Printf("Constructed DID (decimal): %s\n", didInt.String())
+	// 2. DID: the circuit re-derives it from the (secret and) attributes
+	//    MiMC(DIDDomain, version, [secret,] name, nation, address, age, identityID, attrValue)
+	didInt := deriveDID(attrs, secret)
 
 	if did.Cmp(didInt) != 0 {
-		log.Fatalf("did not match")
+		return nil, ErrDIDMismatch
+	}
+	if err := checkSalt(salt); err != nil {
+		return nil, err
 	}
 
-	// 6. Construct the assignment
+	// 3. Construct the assignment
 	assign := &circuits.Circuit{
-		PolicyID:  big.NewInt(policyID),
-		Version:   big.NewInt(version),
-		C:         C,
-		Threshold: big.NewInt(threshold),
-
-		Name:       nameInt,
-		Age:        ageInt,
-		Nation:     nationInt,
-		Address:    addressInt,
-		IdentityID: identityInt,
-		AttrValue:  attrInt,
+		PolicyID:   policyInt,
+		Version:    versionInt,
+		C:          C,
+		Threshold:  threshold,
+		UpperBound: upperBound,
+
+		Name:       attrs.Name,
+		Age:        attrs.Age,
+		Nation:     attrs.Nation,
+		Address:    attrs.Address,
+		IdentityID: attrs.IdentityID,
+		AttrValue:  attrs.AttrValue,
 		DID:        didInt,
+		DIDVersion: big.NewInt(int64(didVersionOf(secret))),
+		Secret:     secretInt,
+		Salt:       salt,
+
+		// no nullifier unless SetScope is called
+		Scope:     big.NewInt(0),
+		Nullifier: big.NewInt(0),
 	}
 
 	return assign, nil
 }
 
-// ComputeLocalDID computes the DID locally using the exact same
-// hashing scheme used in the witness constructor.
+// SetIssuer assigns the issuer key, its signature on the assignment's
+// commitment C, and its path in the trusted set issuers, whose root becomes
+// the public IssuersRoot. Signature and membership are checked off-circuit
+// first, so failures are reported as ErrBadSignature or ErrNotInSet rather
+// than as an unsatisfied constraint.
+func SetIssuer(assignment *circuits.Circuit, issuers *issuer.Set, pub *issuer.PublicKey, sig []byte) error {
+	if pub == nil || sig == nil {
+		return ErrUnsigned
+	}
+	if issuers == nil {
+		return fmt.Errorf("no trusted issuer set")
+	}
+	C, ok := assignment.C.(*big.Int)
+	if !ok {
+		return fmt.Errorf("assignment has no commitment")
+	}
+	if err := issuer.Verify(pub, C, sig); err != nil {
+		return err
+	}
+	rx, ry, sigS, err := issuer.SignatureParts(sig)
+	if err != nil {
+		return err
+	}
+	index, path, err := issuers.Path(pub)
+	if err != nil {
+		return err
+	}
+	x, y := issuer.Point(pub)
+
+	assignment.IssuersRoot = issuers.Root()
+	assignment.IssuerIndex = new(big.Int).SetUint64(index)
+	for i := range assignment.IssuerPath {
+		assignment.IssuerPath[i] = path[i]
+	}
+	assignment.IssuerKey.A.X = x
+	assignment.IssuerKey.A.Y = y
+	assignment.Signature.R.X = rx
+	assignment.Signature.R.Y = ry
+	assignment.Signature.S = sigS
+	return nil
+}
+
+// ComputeLocalDID computes the DID locally. DIDv1 and DIDv2 are the schemes
+// that the circuit enforces (see circuits.Circuit.DeriveDID); DIDLegacy
+// reproduces DIDs issued by the old Keccak concatenation and cannot be used
+// for proving. secret is required for DIDv2 and must be nil otherwise.
 // The returned *big.Int can be compared with the DID provided by the Oracle.
 func ComputeLocalDID(
+	version DIDVersion,
 	name, nation, address string,
 	age, identityID int64,
 	attrValue []byte, // fingerprint bytes
-) *big.Int {
-
-	// Convert integers to bytes
-	ageBytes := big.NewInt(age).Bytes()
-	idBytes := big.NewInt(identityID).Bytes()
-
-	// Keccak256(name + nation + address + age + identityID + attrValue)
-	hasher := sha3.NewLegacyKeccak256()
-	hasher.Write([]byte(name))
-	hasher.Write([]byte(nation))
-	hasher.Write([]byte(address))
-	hasher.Write(ageBytes)
-	hasher.Write(idBytes)
-	hasher.Write(attrValue)
-
-	sum := hasher.Sum(nil)
+	secret *big.Int,
+) (*big.Int, error) {
+	switch version {
+	case DIDLegacy:
+		if secret != nil {
+			return nil, fmt.Errorf("legacy DIDs take no secret")
+		}
+		return legacyDID(name, nation, address, age, identityID, attrValue), nil
+	case DIDv1:
+		if secret != nil {
+			return nil, fmt.Errorf("DIDv1 takes no secret, use DIDv2")
+		}
+	case DIDv2:
+		if err := checkSecret(secret); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown DID version %d", version)
+	}
 
-	didInt := new(big.Int).SetBytes(sum)
-	log.Printf("Locally computed DID (decimal): %s\n", didInt.String())
-	return didInt
+	attrs, err := encoding.EncodeAttributes(name, nation, address, age, identityID, attrValue)
+	if err != nil {
+		return nil, err
+	}
+	return deriveDID(attrs, secret), nil
 }
 
 // ComputeCommitment computes the public commitment C that the circuit enforces:
@@ -135,198 +279,169 @@ func ComputeLocalDID(
 //	    Age,
 //	    NationHash,
 //	    AddressHash,
-//	    IdentityIDHash,
+//	    IdentityID,
 //	    AttrValueHash,
 //	    DID,
+//	    Salt,
 //	)
 //
 // The hashing / preprocessing must be exactly the same as in NewAssignmentCircuit
-// and in your Circuit.Define() MiMC.Write(...) order.
+// and in your Circuit.Define() MiMC.Write(...) order. Attribute encodings follow
+// package encoding.
 func ComputeCommitment(
 	policyID, version int64,
 	name, nation, address string,
 	age, identityID int64,
 	attrValue []byte,
-	did *big.Int,
-) *big.Int {
+	secret *big.Int, // holder secret, nil for DIDv1
+	did, salt *big.Int,
+) (*big.Int, error) {
 
 	// 为了避免和电路不一致，这里直接复用 NewAssignmentCircuit 的逻辑，
-	// 保证 Name/Nation/Address/IdentityID/AttrValue/DID 的 Keccak 预处理完全一致。
+	// 保证 Name/Nation/Address/IdentityID/AttrValue/DID 的预处理完全一致。
 	assignment, err := NewAssignmentCircuit(
-		policyID, version /* threshold = */, 0, // 阈值不参与哈希，随便给个 0
+		policyID, version,
+		big.NewInt(0), big.NewInt(0), // 阈值不参与哈希，随便给个 0
 		name, nation, address,
 		age, identityID,
-		attrValue,
-		did, big.NewInt(0), // C 不参与哈希，随便给个 0 值
+		attrValue, secret,
+		did, salt, big.NewInt(0), // C 不参与哈希，随便给个 0 值
 	)
 	if err != nil {
-		log.Fatalf("ComputeCommitment: failed to build assignment: %v", err)
+		return nil, fmt.Errorf("ComputeCommitment: %w", err)
 	}
 
 	// 从 assignment 里把各字段按电路里的顺序取出来
 	//（在 NewAssignmentCircuit 中，这些字段都被赋值为 *big.Int）
-	getBig := func(v interface{}) *big.Int {
-		if v == nil {
-			log.Fatalf("ComputeCommitment: got nil field in assignment")
-		}
-		bi, ok := v.(*big.Int)
-		if !ok {
-			log.Fatalf("ComputeCommitment: expected *big.Int, got %T", v)
-		}
-		return bi
-	}
-
-	inputs := []*big.Int{
-		getBig(assignment.PolicyID),
-		getBig(assignment.Version),
-		getBig(assignment.Name),
-		getBig(assignment.Age),
-		getBig(assignment.Nation),
-		getBig(assignment.Address),
-		getBig(assignment.IdentityID),
-		getBig(assignment.AttrValue),
-		getBig(assignment.DID),
-	}
+	C := commitmentOf(
+		assignment.PolicyID.(*big.Int),
+		assignment.Version.(*big.Int),
+		encoding.Attributes{
+			Name:       assignment.Name.(*big.Int),
+			Nation:     assignment.Nation.(*big.Int),
+			Address:    assignment.Address.(*big.Int),
+			Age:        assignment.Age.(*big.Int),
+			IdentityID: assignment.IdentityID.(*big.Int),
+			AttrValue:  assignment.AttrValue.(*big.Int),
+		},
+		assignment.DID.(*big.Int),
+		assignment.Salt.(*big.Int),
+	)
+	return C, nil
+}
 
-	// 使用 gnark-crypto 的 MiMC（bn254/fr）做哈希，
-	// 这和 gnark/std/hash/mimc gadget 底层是一套参数。
-	h := frhashmimc.NewMiMC()
+// commitmentOf hashes the commitment inputs in circuit order with
+// merkle.Hash, the off-circuit MiMC.
+func commitmentOf(policyID, version *big.Int, a encoding.Attributes, did, salt *big.Int) *big.Int {
+	return merkle.Hash(
+		policyID,
+		version,
+		a.Name,
+		a.Age,
+		a.Nation,
+		a.Address,
+		a.IdentityID,
+		a.AttrValue,
+		did,
+		salt,
+	)
+}
 
-	// 把每个 field element 规范化成 fr.Element 再写入
-	for _, x := range inputs {
-		var fe fr.Element
-		fe.SetBigInt(x)       // big.Int -> F_r 元素
-		h.Write(fe.Marshal()) // 以标准字节编码写入
+// CheckPredicate evaluates the policy predicate off-circuit on an assignment
+// built by NewAssignmentCircuit.
+func CheckPredicate(policy circuits.Policy, assignment *circuits.Circuit) error {
+	p := policy.Predicate
+	value := assignment.Attribute(p.Attribute).(*big.Int)
+	if !p.Holds(value, assignment.Threshold.(*big.Int), assignment.UpperBound.(*big.Int)) {
+		return fmt.Errorf("policy %d: %s: %w", policy.ID, p, ErrPredicateUnsatisfied)
 	}
-
-	sum := h.Sum(nil)
-
-	// 输出也是一个 F_r 元素（字段内的 hash 值）
-	var out fr.Element
-
-	sumBigInt := new(big.Int).SetBytes(sum)
-	sumBigInt.Mod(sumBigInt, fr.Modulus()) // reduce into field
-	out.SetBigInt(sumBigInt)
-
-	C := out.BigInt(new(big.Int))
-	log.Printf("Locally computed Commitment C (decimal): %s\n", C.String())
-	return C
+	return nil
 }
 
-func GenerateProof(
-	policyID, version, threshold int64,
-	name, nation, address string,
-	age, identityID int64,
-	attrValue []byte, // fingerprint features (bytes)
-	did, C *big.Int,
-) ([]*big.Int, []string, error) {
-	log.Println("Generating proof...")
-
-	// 1. Compile the circuit
-	var circuit circuits.Circuit
-	field := fr.Modulus()                                         // Returns the modulus (*big.Int), field of the curve
-	cs, err := frontend.Compile(field, r1cs.NewBuilder, &circuit) // Build the ConstraintSystem
+// Prove builds the full witness of assignment, a Circuit, MembershipCircuit
+// or RateLimitCircuit, and runs the Groth16 prover.
+func Prove(
+	cs constraint.ConstraintSystem,
+	pk groth16.ProvingKey,
+	assignment frontend.Circuit,
+) (groth16.Proof, witness.Witness, error) {
+	full, err := frontend.NewWitness(assignment, fr.Modulus())
 	if err != nil {
-		return nil, nil, fmt.Errorf("circuit compilation failed: %w", err)
+		return nil, nil, fmt.Errorf("failed to construct witness: %w", err)
+	}
+	proof, err := groth16.Prove(cs, pk, full)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate proof: %w", err)
 	}
+	return proof, full, nil
+}
 
-	// 2. Construct witness (private input + public input)
-	assignment, err := NewAssignmentCircuit(
-		policyID, version, threshold,
-		name, nation, address,
-		age, identityID,
-		attrValue, did, C,
-	)
+// GenerateProof proves a credential with the circuit and ProvingKey stored
+// for its policy version in st, and returns it as a proof bundle; the
+// caller decides where to save it (see bundle.Save). The bundle records the
+// fingerprint of the stored VerifyingKey, so a verifier can tell which key
+// it is meant for. It reloads the key on every call; services should keep a
+// zkid.Prover instead, which loads the key once. Nothing is logged; the
+// Solidity forms of the proof are in package solidity.
+func GenerateProof(st *store.Store, cred *Credential, opts ProofOptions) (*bundle.Bundle, error) {
+	// 1. Load the compiled circuit of the credential's policy (hash-checked
+	//    against the manifest written with the keys, no recompilation)
+	policy, err := circuits.LookupPolicy(cred.PolicyID)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to build assignment: %w", err)
+		return nil, err
 	}
-	witness, err := frontend.NewWitness(assignment, field)
+	cs, err := st.LoadConstraintSystem(cred.PolicyID, cred.Version)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to construct witness: %w", err)
+		return nil, err
 	}
 
-	// 3. Load pk
-	fpk, err := os.Open("./age_pk.bin")
+	// 2. Construct witness (private input + public input)
+	assignment, err := cred.Assignment(opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to open pk file: %w", err)
+		return nil, fmt.Errorf("failed to build assignment: %w", err)
 	}
-	defer fpk.Close()
-
-	// var pk groth16.ProvingKey
-	pk := groth16.NewProvingKey(ecc.BN254)
-	if _, err := pk.ReadFrom(fpk); err != nil {
-		return nil, nil, fmt.Errorf("failed to read pk: %w", err)
+	if err := CheckPredicate(policy, assignment); err != nil {
+		return nil, err
 	}
-
-	// 4. Generate proof
-	pIface, err := groth16.Prove(cs, pk, witness)
+	circuit, err := PolicyAssignment(policy, assignment, opts.Commitments, opts.Message)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to generate proof: %w", err)
+		return nil, err
 	}
 
-	pStruct, ok := pIface.(*groth16_bn254.Proof)
-	if !ok {
-		return nil, nil, fmt.Errorf("failed to cast proof to bn254.Proof")
+	// 3. Load pk, and vk for the bundle fingerprint
+	pk, err := st.LoadProvingKey(cred.PolicyID, cred.Version)
+	if err != nil {
+		return nil, err
 	}
-	ExportProofForSol(*pStruct)
-
-	file, err := os.Create("proof_age.bin")
+	vk, err := st.LoadVerifyingKey(cred.PolicyID, cred.Version)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create proof file: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	if _, err := pIface.WriteTo(file); err != nil {
-		return nil, nil, fmt.Errorf("failed to write proof: %w", err)
+	// 4. Generate proof
+	proof, witness, err := Prove(cs, pk, circuit)
+	if err != nil {
+		return nil, err
 	}
-	log.Println("Successfully generated proof_age.bin")
 
-	// 5. Prepare public inputs for user's verification
-	publicInputs := []*big.Int{
-		big.NewInt(policyID),
-		big.NewInt(version),
-		C,
-		big.NewInt(threshold),
+	// 5. Bundle the proof with its public inputs, in circuit order
+	public, err := publicVector(witness)
+	if err != nil {
+		return nil, err
 	}
-	pubInputsStr := ExportPublicInputs(witness)
-	return publicInputs, pubInputsStr, nil
+	return bundle.New(cred.PolicyID, cred.Version, public, vk, proof)
 }
 
-func ExportProofForSol(proof groth16_bn254.Proof) {
-	// A point
-	a := [2]string{
-		proof.Ar.X.String(),
-		proof.Ar.Y.String(),
-	}
-
-	// B point (G2)
-	b := [2][2]string{
-		{proof.Bs.X.A0.String(), proof.Bs.X.A1.String()},
-		{proof.Bs.Y.A0.String(), proof.Bs.Y.A1.String()},
-	}
-
-	// C point
-	c := [2]string{
-		proof.Krs.X.String(),
-		proof.Krs.Y.String(),
+// publicVector returns the public part of a full witness in circuit order.
+func publicVector(w witness.Witness) ([]*big.Int, error) {
+	public, err := w.Public()
+	if err != nil {
+		return nil, err
 	}
-
-	log.Println("======ExportProofForSolidity: =======")
-	fmt.Println("a:", a)
-	fmt.Println("b:", b)
-	fmt.Println("c:", c)
-}
-
-func ExportPublicInputs(w witness.Witness) []string {
-	public, _ := w.Public()
-
 	elems := public.Vector().(fr.Vector)
-	result := []string{}
-
-	for _, e := range elems {
-		bi := e.BigInt(new(big.Int)) // fr.Element → *big.Int
-		result = append(result, bi.String())
+	out := make([]*big.Int, len(elems))
+	for i := range elems {
+		out[i] = elems[i].BigInt(new(big.Int))
 	}
-
-	return result
+	return out, nil
 }
@@ -0,0 +1,71 @@
+// Rate-limit mode witness, see circuits.RateLimitCircuit: the holder spends
+// one message index of the verifier's epoch per proof.
+package proof_age
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/encoding"
+	"github.com/kanthub/zkid-zkp/merkle"
+	"github.com/kanthub/zkid-zkp/nullifier"
+)
+
+// Message selects the rate-limit nullifier of a proof: the verifier's
+// current epoch (see nullifier.Epoch), the verifier's limit of messages per
+// epoch, and the holder's message index, starting at 0 in every epoch.
+type Message struct {
+	Epoch *big.Int
+	Index uint64
+	Limit uint64
+}
+
+// ComputeRateLimitNullifier returns the nullifier of a holder for message
+// index of epoch, as enforced by the circuit:
+// MiMC(RateLimitDomain, DID, Secret, Epoch, Index). secret is nil for DIDv1.
+func ComputeRateLimitNullifier(did, secret, epoch *big.Int, index uint64) *big.Int {
+	if secret == nil {
+		secret = big.NewInt(0)
+	}
+	return merkle.Hash(circuits.RateLimitDomain, did, secret, epoch, new(big.Int).SetUint64(index))
+}
+
+// RateLimitAssignment wraps a Circuit assignment without scope for a
+// rate-limit-mode policy, spending message index msg.Index of msg.Epoch. As
+// in MembershipAssignment, C is looked up in commitments. An index at or
+// above the limit fails with nullifier.ErrRateLimited.
+func RateLimitAssignment(assignment *circuits.Circuit, commitments *merkle.Tree, msg *Message) (*circuits.RateLimitCircuit, error) {
+	if msg == nil {
+		return nil, fmt.Errorf("rate-limit mode needs the epoch and message index")
+	}
+	if scope, ok := assignment.Scope.(*big.Int); ok && scope.Sign() != 0 {
+		return nil, fmt.Errorf("rate-limit policies take no nullifier scope")
+	}
+	epoch, err := encoding.Element("epoch", msg.Epoch)
+	if err != nil {
+		return nil, err
+	}
+	if msg.Limit == 0 || msg.Limit >= 1<<circuits.MessageLimitBits {
+		return nil, fmt.Errorf("message limit %d out of range", msg.Limit)
+	}
+	if msg.Index >= msg.Limit {
+		return nil, fmt.Errorf("%w: message index %d, limit %d", nullifier.ErrRateLimited, msg.Index, msg.Limit)
+	}
+	did, ok1 := assignment.DID.(*big.Int)
+	secret, ok2 := assignment.Secret.(*big.Int)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("assignment has no DID")
+	}
+	membership, err := MembershipAssignment(assignment, commitments)
+	if err != nil {
+		return nil, err
+	}
+	return circuits.NewRateLimitCircuit(
+		membership,
+		epoch,
+		new(big.Int).SetUint64(msg.Index),
+		new(big.Int).SetUint64(msg.Limit),
+		ComputeRateLimitNullifier(did, secret, epoch, msg.Index),
+	), nil
+}
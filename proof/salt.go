@@ -0,0 +1,90 @@
+// Blinding factor (salt) for the attribute commitment.
+// The salt is a uniformly random field element that only the holder (and the
+// issuer who computed C) knows. Without it, C cannot be brute-forced from
+// guessed attributes. Losing it makes the commitment unusable, so keep it
+// next to the credential.
+package proof_age
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+// GenerateSalt draws a uniformly random non-zero element of F_r from crypto/rand.
+func GenerateSalt() (*big.Int, error) {
+	return randomElement()
+}
+
+// SaveSalt writes the salt as hex to path, readable by the owner only.
+func SaveSalt(path string, salt *big.Int) error {
+	if err := checkSalt(salt); err != nil {
+		return err
+	}
+	return writeElement(path, salt)
+}
+
+// LoadSalt reads a salt written by SaveSalt.
+func LoadSalt(path string) (*big.Int, error) {
+	salt, err := readElement(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSalt(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+func checkSalt(salt *big.Int) error {
+	if !isNonZeroElement(salt) {
+		return fmt.Errorf("salt must be a non-zero element of F_r")
+	}
+	return nil
+}
+
+// randomElement draws a uniformly random non-zero element of F_r.
+func randomElement() (*big.Int, error) {
+	for {
+		// 64 bytes reduced mod r keeps the bias negligible
+		buf := make([]byte, 64)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, fmt.Errorf("failed to read randomness: %w", err)
+		}
+		x := new(big.Int).SetBytes(buf)
+		x.Mod(x, fr.Modulus())
+		if x.Sign() != 0 {
+			return x, nil
+		}
+	}
+}
+
+func isNonZeroElement(x *big.Int) bool {
+	return x != nil && x.Sign() > 0 && x.Cmp(fr.Modulus()) < 0
+}
+
+// writeElement stores a field element as 32-byte big-endian hex, mode 0600.
+func writeElement(path string, x *big.Int) error {
+	data := hex.EncodeToString(x.FillBytes(make([]byte, fr.Bytes)))
+	if err := os.WriteFile(path, []byte(data+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func readElement(path string) (*big.Int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
@@ -0,0 +1,76 @@
+// Holder secret for secret-seeded (DIDv2) DIDs.
+// The secret never leaves the holder. It can be a random seed generated with
+// GenerateSecret, or be derived from a mnemonic-style phrase the holder can
+// write down and re-enter with SecretFromPhrase.
+package proof_age
+
+import (
+	"crypto/pbkdf2"
+	"crypto/sha512"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+)
+
+const (
+	// MinPhraseWords is the minimum number of words accepted by SecretFromPhrase.
+	MinPhraseWords = 12
+
+	phraseSalt       = "zkid/did-secret"
+	phraseIterations = 210_000
+)
+
+// GenerateSecret draws a uniformly random non-zero holder secret.
+func GenerateSecret() (*big.Int, error) {
+	return randomElement()
+}
+
+// SecretFromPhrase derives the holder secret from a phrase of at least
+// MinPhraseWords words. Words are lower-cased and separated by single spaces
+// before stretching with PBKDF2-HMAC-SHA512, so the same phrase always yields
+// the same secret.
+func SecretFromPhrase(phrase string) (*big.Int, error) {
+	words := strings.Fields(strings.ToLower(phrase))
+	if len(words) < MinPhraseWords {
+		return nil, fmt.Errorf("phrase has %d words, need at least %d", len(words), MinPhraseWords)
+	}
+	key, err := pbkdf2.Key(sha512.New, strings.Join(words, " "), []byte(phraseSalt), phraseIterations, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stretch phrase: %w", err)
+	}
+	secret := new(big.Int).SetBytes(key)
+	secret.Mod(secret, fr.Modulus())
+	if err := checkSecret(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// SaveSecret writes the secret as hex to path, readable by the owner only.
+func SaveSecret(path string, secret *big.Int) error {
+	if err := checkSecret(secret); err != nil {
+		return err
+	}
+	return writeElement(path, secret)
+}
+
+// LoadSecret reads a secret written by SaveSecret.
+func LoadSecret(path string) (*big.Int, error) {
+	secret, err := readElement(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSecret(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+func checkSecret(secret *big.Int) error {
+	if !isNonZeroElement(secret) {
+		return fmt.Errorf("secret must be a non-zero element of F_r")
+	}
+	return nil
+}
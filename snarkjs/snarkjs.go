@@ -0,0 +1,403 @@
+// snarkjs Groth16 JSON layout for proofs, public inputs and verifying keys.
+//
+// snarkjs writes points in projective form with decimal coordinates, in
+// practice normalised to z = 1:
+//
+//	G1: ["x", "y", "1"]
+//	G2: [["x.a0", "x.a1"], ["y.a0", "y.a1"], ["1", "0"]]
+//
+// G2 coefficients are real part first, unlike the Solidity calldata, which
+// puts the imaginary part first. The curve is called "bn128" there. gnark's
+// K points are snarkjs's IC: K[0] is the constant term of the "one" wire and
+// K[1..] belong to the public inputs in circuit order.
+package snarkjs
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+const (
+	Protocol = "groth16"
+	Curve    = "bn128"
+
+	ProofFile           = "proof.json"
+	PublicFile          = "public.json"
+	VerificationKeyFile = "verification_key.json"
+)
+
+// ErrFormat is returned for JSON that is not a valid snarkjs Groth16 object.
+var ErrFormat = errors.New("malformed snarkjs object")
+
+// Proof is the content of proof.json.
+type Proof struct {
+	PiA      []string   `json:"pi_a"`
+	PiB      [][]string `json:"pi_b"`
+	PiC      []string   `json:"pi_c"`
+	Protocol string     `json:"protocol"`
+	Curve    string     `json:"curve"`
+}
+
+// VerificationKey is the content of verification_key.json.
+type VerificationKey struct {
+	Protocol      string       `json:"protocol"`
+	Curve         string       `json:"curve"`
+	NPublic       int          `json:"nPublic"`
+	VkAlpha1      []string     `json:"vk_alpha_1"`
+	VkBeta2       [][]string   `json:"vk_beta_2"`
+	VkGamma2      [][]string   `json:"vk_gamma_2"`
+	VkDelta2      [][]string   `json:"vk_delta_2"`
+	VkAlphabeta12 [][][]string `json:"vk_alphabeta_12"`
+	IC            [][]string   `json:"IC"`
+}
+
+// ExportProof converts a BN254 Groth16 proof to proof.json.
+func ExportProof(proof groth16.Proof) (*Proof, error) {
+	p, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return nil, fmt.Errorf("expected a BN254 proof, got %T", proof)
+	}
+	if len(p.Commitments) != 0 {
+		return nil, fmt.Errorf("proof has %d commitments, snarkjs Groth16 takes none", len(p.Commitments))
+	}
+	return &Proof{
+		PiA:      g1JSON(&p.Ar),
+		PiB:      g2JSON(&p.Bs),
+		PiC:      g1JSON(&p.Krs),
+		Protocol: Protocol,
+		Curve:    Curve,
+	}, nil
+}
+
+// Groth16 converts proof.json back into a gnark proof.
+func (p *Proof) Groth16() (groth16.Proof, error) {
+	if err := checkHeader(p.Protocol, p.Curve); err != nil {
+		return nil, err
+	}
+	var out groth16_bn254.Proof
+	var err error
+	if out.Ar, err = parseG1(p.PiA); err != nil {
+		return nil, fmt.Errorf("pi_a: %w", err)
+	}
+	if out.Bs, err = parseG2(p.PiB); err != nil {
+		return nil, fmt.Errorf("pi_b: %w", err)
+	}
+	if out.Krs, err = parseG1(p.PiC); err != nil {
+		return nil, fmt.Errorf("pi_c: %w", err)
+	}
+	return &out, nil
+}
+
+// ExportPublic converts public inputs to public.json: decimal strings in
+// circuit order.
+func ExportPublic(public []*big.Int) []string {
+	out := make([]string, len(public))
+	for i, x := range public {
+		out[i] = x.String()
+	}
+	return out
+}
+
+// ParsePublic reads public.json values. Non-canonical values (≥ r) are
+// rejected rather than reduced.
+func ParsePublic(public []string) ([]*big.Int, error) {
+	out := make([]*big.Int, len(public))
+	for i, s := range public {
+		x, ok := new(big.Int).SetString(s, 10)
+		if !ok || x.Sign() < 0 || x.Cmp(fr.Modulus()) >= 0 {
+			return nil, fmt.Errorf("%w: public input %d is not in [0, r)", ErrFormat, i)
+		}
+		out[i] = x
+	}
+	return out, nil
+}
+
+// ExportVerifyingKey converts a BN254 VerifyingKey to verification_key.json.
+func ExportVerifyingKey(vk groth16.VerifyingKey) (*VerificationKey, error) {
+	key, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("expected a BN254 verifying key, got %T", vk)
+	}
+	if len(key.PublicAndCommitmentCommitted) != 0 {
+		return nil, fmt.Errorf("verifying key has commitments, snarkjs Groth16 takes none")
+	}
+	if len(key.G1.K) == 0 {
+		return nil, fmt.Errorf("verifying key has no K points")
+	}
+	e, err := bn254.Pair([]bn254.G1Affine{key.G1.Alpha}, []bn254.G2Affine{key.G2.Beta})
+	if err != nil {
+		return nil, err
+	}
+
+	out := &VerificationKey{
+		Protocol: Protocol,
+		Curve:    Curve,
+		NPublic:  len(key.G1.K) - 1,
+		VkAlpha1: g1JSON(&key.G1.Alpha),
+		VkBeta2:  g2JSON(&key.G2.Beta),
+		VkGamma2: g2JSON(&key.G2.Gamma),
+		VkDelta2: g2JSON(&key.G2.Delta),
+		VkAlphabeta12: [][][]string{
+			{e2JSON(e.C0.B0), e2JSON(e.C0.B1), e2JSON(e.C0.B2)},
+			{e2JSON(e.C1.B0), e2JSON(e.C1.B1), e2JSON(e.C1.B2)},
+		},
+	}
+	for i := range key.G1.K {
+		out.IC = append(out.IC, g1JSON(&key.G1.K[i]))
+	}
+	return out, nil
+}
+
+// Groth16 converts verification_key.json into a gnark VerifyingKey ready
+// for groth16.Verify. vk_alphabeta_12 is not read; it is recomputed.
+func (k *VerificationKey) Groth16() (groth16.VerifyingKey, error) {
+	if err := checkHeader(k.Protocol, k.Curve); err != nil {
+		return nil, err
+	}
+	if len(k.IC) != k.NPublic+1 {
+		return nil, fmt.Errorf("%w: %d IC points for nPublic %d", ErrFormat, len(k.IC), k.NPublic)
+	}
+	var vk groth16_bn254.VerifyingKey
+	var err error
+	if vk.G1.Alpha, err = parseG1(k.VkAlpha1); err != nil {
+		return nil, fmt.Errorf("vk_alpha_1: %w", err)
+	}
+	if vk.G2.Beta, err = parseG2(k.VkBeta2); err != nil {
+		return nil, fmt.Errorf("vk_beta_2: %w", err)
+	}
+	if vk.G2.Gamma, err = parseG2(k.VkGamma2); err != nil {
+		return nil, fmt.Errorf("vk_gamma_2: %w", err)
+	}
+	if vk.G2.Delta, err = parseG2(k.VkDelta2); err != nil {
+		return nil, fmt.Errorf("vk_delta_2: %w", err)
+	}
+	vk.G1.K = make([]bn254.G1Affine, len(k.IC))
+	for i := range k.IC {
+		if vk.G1.K[i], err = parseG1(k.IC[i]); err != nil {
+			return nil, fmt.Errorf("IC[%d]: %w", i, err)
+		}
+	}
+	if err := vk.Precompute(); err != nil {
+		return nil, err
+	}
+	return &vk, nil
+}
+
+// WriteFiles writes proof.json, public.json and verification_key.json to dir.
+func WriteFiles(dir string, proof groth16.Proof, public []*big.Int, vk groth16.VerifyingKey) error {
+	p, err := ExportProof(proof)
+	if err != nil {
+		return err
+	}
+	k, err := ExportVerifyingKey(vk)
+	if err != nil {
+		return err
+	}
+	if k.NPublic != len(public) {
+		return fmt.Errorf("verifying key takes %d public inputs, got %d", k.NPublic, len(public))
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	for _, f := range []struct {
+		name string
+		v    any
+	}{
+		{ProofFile, p},
+		{PublicFile, ExportPublic(public)},
+		{VerificationKeyFile, k},
+	} {
+		data, err := json.MarshalIndent(f.v, "", " ")
+		if err != nil {
+			return err
+		}
+		path := filepath.Join(dir, f.name)
+		if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// ReadFiles reads the three snarkjs files from the given paths.
+func ReadFiles(proofPath, publicPath, vkPath string) (groth16.Proof, []*big.Int, groth16.VerifyingKey, error) {
+	proof, inputs, err := ReadProof(proofPath, publicPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	vk, err := ReadVerifyingKey(vkPath)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if n := len(vk.(*groth16_bn254.VerifyingKey).G1.K) - 1; n != len(inputs) {
+		return nil, nil, nil, fmt.Errorf("%s takes %d public inputs, %s has %d", vkPath, n, publicPath, len(inputs))
+	}
+	return proof, inputs, vk, nil
+}
+
+// ReadProof reads proof.json and public.json from the given paths.
+func ReadProof(proofPath, publicPath string) (groth16.Proof, []*big.Int, error) {
+	var (
+		p      Proof
+		public []string
+	)
+	if err := readJSON(proofPath, &p); err != nil {
+		return nil, nil, err
+	}
+	if err := readJSON(publicPath, &public); err != nil {
+		return nil, nil, err
+	}
+	proof, err := p.Groth16()
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", proofPath, err)
+	}
+	inputs, err := ParsePublic(public)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", publicPath, err)
+	}
+	return proof, inputs, nil
+}
+
+// ReadVerifyingKey reads verification_key.json from path. A key read from
+// a file handed over with the proof proves nothing by itself; compare its
+// FingerprintOf with the one of a trusted key before using it.
+func ReadVerifyingKey(path string) (groth16.VerifyingKey, error) {
+	var k VerificationKey
+	if err := readJSON(path, &k); err != nil {
+		return nil, err
+	}
+	vk, err := k.Groth16()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return vk, nil
+}
+
+// FingerprintOf returns the SHA-256 of the verification_key.json form of vk.
+// It covers only what snarkjs keeps of a key (alpha, beta, gamma, delta and
+// IC), so a key read back from JSON has the fingerprint of the original.
+func FingerprintOf(vk groth16.VerifyingKey) ([32]byte, error) {
+	k, err := ExportVerifyingKey(vk)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	k.VkAlphabeta12 = nil // derived from alpha and beta
+	data, err := json.Marshal(k)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return sha256.Sum256(data), nil
+}
+
+func readJSON(path string, v any) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("%s: %w: %v", path, ErrFormat, err)
+	}
+	return nil
+}
+
+func checkHeader(protocol, curve string) error {
+	if protocol != Protocol || curve != Curve {
+		return fmt.Errorf("%w: protocol %q on curve %q, want %q on %q", ErrFormat, protocol, curve, Protocol, Curve)
+	}
+	return nil
+}
+
+func g1JSON(p *bn254.G1Affine) []string {
+	if p.IsInfinity() {
+		return []string{"0", "1", "0"}
+	}
+	return []string{p.X.String(), p.Y.String(), "1"}
+}
+
+func g2JSON(q *bn254.G2Affine) [][]string {
+	if q.IsInfinity() {
+		return [][]string{{"0", "0"}, {"1", "0"}, {"0", "0"}}
+	}
+	return [][]string{
+		{q.X.A0.String(), q.X.A1.String()},
+		{q.Y.A0.String(), q.Y.A1.String()},
+		{"1", "0"},
+	}
+}
+
+func e2JSON(e bn254.E2) []string {
+	return []string{e.A0.String(), e.A1.String()}
+}
+
+func parseFp(s string) (fp.Element, error) {
+	var e fp.Element
+	x, ok := new(big.Int).SetString(s, 10)
+	if !ok || x.Sign() < 0 || x.Cmp(fp.Modulus()) >= 0 {
+		return e, fmt.Errorf("%w: coordinate %q is not in [0, p)", ErrFormat, s)
+	}
+	e.SetBigInt(x)
+	return e, nil
+}
+
+// parseG1 reads a G1 point with z = 1, or z = 0 for the point at infinity.
+func parseG1(w []string) (bn254.G1Affine, error) {
+	var p bn254.G1Affine
+	if len(w) != 3 {
+		return p, fmt.Errorf("%w: G1 point needs 3 coordinates", ErrFormat)
+	}
+	if w[2] == "0" {
+		return p, nil
+	}
+	if w[2] != "1" {
+		return p, fmt.Errorf("%w: G1 point is not normalised (z = %s)", ErrFormat, w[2])
+	}
+	var err error
+	if p.X, err = parseFp(w[0]); err != nil {
+		return p, err
+	}
+	if p.Y, err = parseFp(w[1]); err != nil {
+		return p, err
+	}
+	if !p.IsOnCurve() {
+		return p, fmt.Errorf("%w: G1 point not on curve", ErrFormat)
+	}
+	return p, nil
+}
+
+// parseG2 reads a G2 point with z = (1, 0), or z = (0, 0) for the point at
+// infinity.
+func parseG2(w [][]string) (bn254.G2Affine, error) {
+	var q bn254.G2Affine
+	if len(w) != 3 || len(w[0]) != 2 || len(w[1]) != 2 || len(w[2]) != 2 {
+		return q, fmt.Errorf("%w: G2 point needs 3 pairs of coordinates", ErrFormat)
+	}
+	if w[2][0] == "0" && w[2][1] == "0" {
+		return q, nil
+	}
+	if w[2][0] != "1" || w[2][1] != "0" {
+		return q, fmt.Errorf("%w: G2 point is not normalised", ErrFormat)
+	}
+	coords := []*fp.Element{&q.X.A0, &q.X.A1, &q.Y.A0, &q.Y.A1}
+	for i, s := range []string{w[0][0], w[0][1], w[1][0], w[1][1]} {
+		e, err := parseFp(s)
+		if err != nil {
+			return q, err
+		}
+		*coords[i] = e
+	}
+	if !q.IsOnCurve() || !q.IsInSubGroup() {
+		return q, fmt.Errorf("%w: G2 point not in the subgroup", ErrFormat)
+	}
+	return q, nil
+}
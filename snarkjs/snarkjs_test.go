@@ -0,0 +1,130 @@
+package snarkjs
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+
+	"github.com/kanthub/zkid-zkp/internal/testcircuit"
+)
+
+// TestRoundTrip exports a proof, its public inputs and its verifying key,
+// reads them back and verifies.
+func TestRoundTrip(t *testing.T) {
+	f := testcircuit.New(t)
+	vk, proof, public := f.VK, f.Proof, f.Public
+	dir := t.TempDir()
+	if err := WriteFiles(dir, proof, public, vk); err != nil {
+		t.Fatal(err)
+	}
+	gotProof, gotPublic, gotVK, err := ReadFiles(
+		filepath.Join(dir, ProofFile),
+		filepath.Join(dir, PublicFile),
+		filepath.Join(dir, VerificationKeyFile),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gotPublic) != len(public) {
+		t.Fatalf("read %d public inputs, wrote %d", len(gotPublic), len(public))
+	}
+	for i := range public {
+		if gotPublic[i].Cmp(public[i]) != 0 {
+			t.Fatalf("public input %d does not round-trip", i)
+		}
+	}
+	if err := testcircuit.Verify(gotVK, gotProof, gotPublic); err != nil {
+		t.Fatalf("round-tripped proof does not verify: %v", err)
+	}
+
+	want, err := FingerprintOf(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FingerprintOf(gotVK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Fatal("fingerprint changes through verification_key.json")
+	}
+}
+
+// TestVerifyingKeyLayout pins the IC length and the real-part-first order
+// of G2 coefficients.
+func TestVerifyingKeyLayout(t *testing.T) {
+	f := testcircuit.New(t)
+	vk, public := f.VK, f.Public
+	key := vk.(*groth16_bn254.VerifyingKey)
+	k, err := ExportVerifyingKey(vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if k.NPublic != len(public) || len(k.IC) != len(public)+1 || len(k.IC) != len(key.G1.K) {
+		t.Fatalf("nPublic %d and %d IC points for %d public inputs and %d K points",
+			k.NPublic, len(k.IC), len(public), len(key.G1.K))
+	}
+	for i := range key.G1.K {
+		if k.IC[i][0] != key.G1.K[i].X.String() || k.IC[i][1] != key.G1.K[i].Y.String() {
+			t.Fatalf("IC[%d] is not K[%d]", i, i)
+		}
+	}
+	beta := key.G2.Beta
+	want := [][]string{
+		{beta.X.A0.String(), beta.X.A1.String()},
+		{beta.Y.A0.String(), beta.Y.A1.String()},
+		{"1", "0"},
+	}
+	for i := range want {
+		if k.VkBeta2[i][0] != want[i][0] || k.VkBeta2[i][1] != want[i][1] {
+			t.Fatalf("vk_beta_2[%d] = %v, want real part first %v", i, k.VkBeta2[i], want[i])
+		}
+	}
+
+	// a key in the Solidity order (imaginary part first) must not parse
+	swapped := cloneKey(t, k)
+	for i := 0; i < 2; i++ {
+		swapped.VkBeta2[i][0], swapped.VkBeta2[i][1] = swapped.VkBeta2[i][1], swapped.VkBeta2[i][0]
+	}
+	if _, err := swapped.Groth16(); !errors.Is(err, ErrFormat) {
+		t.Fatalf("swapped G2 coefficients: got %v, want ErrFormat", err)
+	}
+
+	short := cloneKey(t, k)
+	short.IC = short.IC[:len(short.IC)-1]
+	if _, err := short.Groth16(); !errors.Is(err, ErrFormat) {
+		t.Fatalf("missing IC point: got %v, want ErrFormat", err)
+	}
+}
+
+// TestFingerprintDiffers checks that another key has another fingerprint.
+func TestFingerprintDiffers(t *testing.T) {
+	f1, err := FingerprintOf(testcircuit.New(t).VK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f2, err := FingerprintOf(testcircuit.New(t).VK)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f1 == f2 {
+		t.Fatal("independent setups have the same fingerprint")
+	}
+}
+
+func cloneKey(t *testing.T, k *VerificationKey) *VerificationKey {
+	t.Helper()
+	data, err := json.Marshal(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out VerificationKey
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatal(err)
+	}
+	return &out
+}
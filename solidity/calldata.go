@@ -0,0 +1,132 @@
+// ABI calldata of the verifier contract.
+//
+// Both entry points take static arrays only, so their calldata is the 4-byte
+// selector followed by every uint256 word in order, without offsets:
+//
+//	verifyProof(uint256[8],uint256[N])           4 + 32·(8+N) bytes
+//	verifyCompressedProof(uint256[4],uint256[N]) 4 + 32·(4+N) bytes
+//
+// N is the number of public inputs of the circuit the contract was exported
+// for; InputCount reads it from the Solidity source. Only the Keccak from
+// golang.org/x/crypto is needed, no Ethereum client library.
+package solidity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// ErrCalldata is returned by the decoders for calldata of the wrong function
+// or length.
+var ErrCalldata = errors.New("malformed calldata")
+
+// VerifyProofSignature returns the canonical signature of verifyProof for
+// nInputs public inputs.
+func VerifyProofSignature(nInputs int) string {
+	return fmt.Sprintf("verifyProof(uint256[8],uint256[%d])", nInputs)
+}
+
+// VerifyCompressedProofSignature returns the canonical signature of
+// verifyCompressedProof for nInputs public inputs.
+func VerifyCompressedProofSignature(nInputs int) string {
+	return fmt.Sprintf("verifyCompressedProof(uint256[4],uint256[%d])", nInputs)
+}
+
+// Selector returns the first four bytes of Keccak256(signature).
+func Selector(signature string) [4]byte {
+	h := sha3.NewLegacyKeccak256()
+	h.Write([]byte(signature))
+	var sel [4]byte
+	copy(sel[:], h.Sum(nil))
+	return sel
+}
+
+var verifyProofInput = regexp.MustCompile(`function\s+verifyProof\s*\(\s*uint256\[8\]\s+calldata\s+\w+\s*,\s*uint256\[(\d+)\]\s+calldata\s+\w+\s*\)`)
+
+// InputCount returns the number of public inputs taken by the verifyProof
+// function of a Solidity verifier source, e.g. an exported Verifier.sol.
+func InputCount(src []byte) (int, error) {
+	m := verifyProofInput.FindSubmatch(src)
+	if m == nil {
+		return 0, fmt.Errorf("no verifyProof(uint256[8], uint256[N]) function in source")
+	}
+	return strconv.Atoi(string(m[1]))
+}
+
+// VerifyProofCalldata encodes a call to verifyProof(proof, input).
+func VerifyProofCalldata(p Proof, input Input) ([]byte, error) {
+	return encodeCall(VerifyProofSignature(len(input)), p[:], input)
+}
+
+// VerifyCompressedProofCalldata encodes a call to
+// verifyCompressedProof(compressedProof, input).
+func VerifyCompressedProofCalldata(c CompressedProof, input Input) ([]byte, error) {
+	return encodeCall(VerifyCompressedProofSignature(len(input)), c[:], input)
+}
+
+// DecodeVerifyProofCalldata decodes calldata produced by VerifyProofCalldata
+// for a contract with nInputs public inputs.
+func DecodeVerifyProofCalldata(data []byte, nInputs int) (Proof, Input, error) {
+	var p Proof
+	input, err := decodeCall(data, VerifyProofSignature(nInputs), p[:], nInputs)
+	if err != nil {
+		return Proof{}, nil, err
+	}
+	return p, input, nil
+}
+
+// DecodeVerifyCompressedProofCalldata decodes calldata produced by
+// VerifyCompressedProofCalldata for a contract with nInputs public inputs.
+func DecodeVerifyCompressedProofCalldata(data []byte, nInputs int) (CompressedProof, Input, error) {
+	var c CompressedProof
+	input, err := decodeCall(data, VerifyCompressedProofSignature(nInputs), c[:], nInputs)
+	if err != nil {
+		return CompressedProof{}, nil, err
+	}
+	return c, input, nil
+}
+
+func encodeCall(signature string, proof []*big.Int, input Input) ([]byte, error) {
+	for i, x := range proof {
+		if x == nil || x.Sign() < 0 || x.BitLen() > 256 {
+			return nil, fmt.Errorf("proof[%d] is not a uint256", i)
+		}
+	}
+	for i, x := range input {
+		if x == nil || x.Sign() < 0 || x.BitLen() > 256 {
+			return nil, fmt.Errorf("input[%d] is not a uint256", i)
+		}
+	}
+	sel := Selector(signature)
+	data := make([]byte, 0, 4+32*(len(proof)+len(input)))
+	data = append(data, sel[:]...)
+	data = append(data, words(proof)...)
+	data = append(data, words(input)...)
+	return data, nil
+}
+
+func decodeCall(data []byte, signature string, proof []*big.Int, nInputs int) (Input, error) {
+	sel := Selector(signature)
+	if len(data) < 4 || !bytes.Equal(data[:4], sel[:]) {
+		return nil, fmt.Errorf("%w: selector is not %s", ErrCalldata, signature)
+	}
+	if want := 4 + 32*(len(proof)+nInputs); len(data) != want {
+		return nil, fmt.Errorf("%w: %d bytes, %s takes %d", ErrCalldata, len(data), signature, want)
+	}
+	body := data[4:]
+	for i := range proof {
+		proof[i] = new(big.Int).SetBytes(body[32*i : 32*(i+1)])
+	}
+	body = body[32*len(proof):]
+	input := make(Input, nInputs)
+	for i := range input {
+		input[i] = new(big.Int).SetBytes(body[32*i : 32*(i+1)])
+	}
+	return input, nil
+}
@@ -0,0 +1,162 @@
+package solidity
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"testing"
+
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/kanthub/zkid-zkp/internal/testcircuit"
+)
+
+// fixture is a valid testcircuit proof with its key and contract.
+type fixture struct {
+	vk       groth16.VerifyingKey
+	proof    groth16.Proof
+	p        Proof
+	input    Input
+	contract []byte // exported Verifier.sol
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	tc := testcircuit.New(t)
+	f := &fixture{vk: tc.VK, proof: tc.Proof, input: tc.Public}
+	var err error
+	if f.p, err = FromGroth16(tc.Proof); err != nil {
+		t.Fatal(err)
+	}
+	var src bytes.Buffer
+	if err := tc.VK.ExportSolidity(&src); err != nil {
+		t.Fatal(err)
+	}
+	f.contract = src.Bytes()
+	return f
+}
+
+func TestCalldataRoundTrip(t *testing.T) {
+	f := newFixture(t)
+	n := len(f.input)
+
+	data, err := VerifyProofCalldata(f.p, f.input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4+32*(8+n) {
+		t.Fatalf("verifyProof calldata is %d bytes", len(data))
+	}
+	p, input, err := DecodeVerifyProofCalldata(data, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalWords(p[:], f.p[:]) || !equalWords(input, f.input) {
+		t.Fatal("verifyProof calldata does not round-trip")
+	}
+
+	c, err := f.p.Compress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = VerifyCompressedProofCalldata(c, f.input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 4+32*(4+n) {
+		t.Fatalf("verifyCompressedProof calldata is %d bytes", len(data))
+	}
+	dc, input, err := DecodeVerifyCompressedProofCalldata(data, n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalWords(dc[:], c[:]) || !equalWords(input, f.input) {
+		t.Fatal("verifyCompressedProof calldata does not round-trip")
+	}
+
+	// the calldata of one function is not accepted as the other's
+	if _, _, err := DecodeVerifyProofCalldata(data, n); !errors.Is(err, ErrCalldata) {
+		t.Fatalf("compressed calldata decoded as verifyProof: %v", err)
+	}
+	if _, _, err := DecodeVerifyCompressedProofCalldata(data[:len(data)-1], n); !errors.Is(err, ErrCalldata) {
+		t.Fatalf("truncated calldata decoded: %v", err)
+	}
+	if _, _, err := DecodeVerifyCompressedProofCalldata(data, n+1); !errors.Is(err, ErrCalldata) {
+		t.Fatalf("calldata decoded with the wrong input count: %v", err)
+	}
+}
+
+func TestSelectors(t *testing.T) {
+	// known answer: ERC-20 transfer
+	if sel := Selector("transfer(address,uint256)"); hex.EncodeToString(sel[:]) != "a9059cbb" {
+		t.Fatalf("Selector(transfer) = %x", sel)
+	}
+
+	f := newFixture(t)
+	n, err := InputCount(f.contract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(f.input) {
+		t.Fatalf("InputCount = %d, circuit has %d public inputs", n, len(f.input))
+	}
+
+	// the selectors solc assigns follow from the parameter types declared in
+	// the exported source
+	for _, tc := range []struct {
+		function, signature string
+	}{
+		{"verifyProof", VerifyProofSignature(n)},
+		{"verifyCompressedProof", VerifyCompressedProofSignature(n)},
+	} {
+		declared, err := declaredSignature(f.contract, tc.function)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if declared != tc.signature {
+			t.Fatalf("contract declares %s, calldata uses %s", declared, tc.signature)
+		}
+	}
+
+	data, err := VerifyProofCalldata(f.p, f.input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sel := Selector(VerifyProofSignature(n)); !bytes.Equal(data[:4], sel[:]) {
+		t.Fatalf("verifyProof calldata starts with %x, not its selector %x", data[:4], sel)
+	}
+}
+
+var solidityParam = regexp.MustCompile(`(uint256(?:\[\d+\])?)\s+calldata\s+\w+`)
+
+// declaredSignature returns the canonical signature of function as declared
+// in a Solidity source.
+func declaredSignature(src []byte, function string) (string, error) {
+	decl := regexp.MustCompile(`function\s+` + function + `\s*\(([^)]*)\)`).FindSubmatch(src)
+	if decl == nil {
+		return "", fmt.Errorf("no function %s in source", function)
+	}
+	var types []byte
+	for i, m := range solidityParam.FindAllSubmatch(decl[1], -1) {
+		if i > 0 {
+			types = append(types, ',')
+		}
+		types = append(types, m[1]...)
+	}
+	return function + "(" + string(types) + ")", nil
+}
+
+func equalWords(a, b []*big.Int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Cmp(b[i]) != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,209 @@
+// Point compression of Verifier.sol, ported operation by operation: the
+// contract picks square roots as a^((p+1)/4), and the compressed form
+// depends on that choice, so gnark-crypto's own compression cannot be used.
+package solidity
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+)
+
+var (
+	modP = fp.Modulus()
+
+	// expSqrt = (p+1)/4, EXP_SQRT_FP of the contract
+	expSqrt = new(big.Int).Rsh(new(big.Int).Add(modP, big.NewInt(1)), 2)
+
+	// 1/2, 27/82 and 3/82 in Fp: 3/(9+i) = 27/82 - 3/82·i is the G2 twist constant
+	half       = fpDiv(big.NewInt(1), big.NewInt(2))
+	frac27Of82 = fpDiv(big.NewInt(27), big.NewInt(82))
+	frac3Of82  = fpDiv(big.NewInt(3), big.NewInt(82))
+)
+
+func fpDiv(a, b *big.Int) *big.Int {
+	inv := new(big.Int).ModInverse(b, modP)
+	return fpMul(a, inv)
+}
+
+func fpMul(a, b *big.Int) *big.Int {
+	x := new(big.Int).Mul(a, b)
+	return x.Mod(x, modP)
+}
+
+func fpAdd(a, b *big.Int) *big.Int {
+	x := new(big.Int).Add(a, b)
+	return x.Mod(x, modP)
+}
+
+func fpNeg(a *big.Int) *big.Int {
+	x := new(big.Int).Neg(a)
+	return x.Mod(x, modP)
+}
+
+func reduced(xs ...*big.Int) bool {
+	for _, x := range xs {
+		if x == nil || x.Sign() < 0 || x.Cmp(modP) >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// sqrtFp is the contract's sqrt_Fp.
+func sqrtFp(a *big.Int) (*big.Int, error) {
+	x := new(big.Int).Exp(a, expSqrt, modP)
+	if fpMul(x, x).Cmp(a) != 0 {
+		return nil, fmt.Errorf("not a square: %w", ErrInvalidPoint)
+	}
+	return x, nil
+}
+
+// isSquareFp is the contract's isSquare_Fp.
+func isSquareFp(a *big.Int) bool {
+	x := new(big.Int).Exp(a, expSqrt, modP)
+	return fpMul(x, x).Cmp(a) == 0
+}
+
+// sqrtFp2 is the contract's sqrt_Fp2.
+func sqrtFp2(a0, a1 *big.Int, hint bool) (x0, x1 *big.Int, err error) {
+	d, err := sqrtFp(fpAdd(fpMul(a0, a0), fpMul(a1, a1)))
+	if err != nil {
+		return nil, nil, err
+	}
+	if hint {
+		d = fpNeg(d)
+	}
+	if x0, err = sqrtFp(fpMul(fpAdd(a0, d), half)); err != nil {
+		return nil, nil, err
+	}
+	inv := new(big.Int).ModInverse(fpMul(x0, big.NewInt(2)), modP)
+	if inv == nil {
+		return nil, nil, ErrInvalidPoint
+	}
+	x1 = fpMul(a1, inv)
+	if a0.Cmp(fpAdd(fpMul(x0, x0), fpNeg(fpMul(x1, x1)))) != 0 ||
+		a1.Cmp(fpMul(big.NewInt(2), fpMul(x0, x1))) != 0 {
+		return nil, nil, ErrInvalidPoint
+	}
+	return x0, x1, nil
+}
+
+// g1RHS is x³ + 3.
+func g1RHS(x *big.Int) *big.Int {
+	return fpAdd(fpMul(fpMul(x, x), x), big.NewInt(3))
+}
+
+// g2RHS is X³ + 3/(9+i) for X = x0 + x1·i.
+func g2RHS(x0, x1 *big.Int) (y0, y1 *big.Int) {
+	n3ab := fpMul(fpMul(x0, x1), new(big.Int).Sub(modP, big.NewInt(3)))
+	a3 := fpMul(fpMul(x0, x0), x0)
+	b3 := fpMul(fpMul(x1, x1), x1)
+	y0 = fpAdd(frac27Of82, fpAdd(a3, fpMul(n3ab, x1)))
+	y1 = fpNeg(fpAdd(frac3Of82, fpAdd(b3, fpMul(n3ab, x0))))
+	return y0, y1
+}
+
+// compressG1 is the contract's compress_g1.
+func compressG1(x, y *big.Int) (*big.Int, error) {
+	if !reduced(x, y) {
+		return nil, ErrInvalidPoint
+	}
+	if x.Sign() == 0 && y.Sign() == 0 {
+		return new(big.Int), nil
+	}
+	yPos, err := sqrtFp(g1RHS(x))
+	if err != nil {
+		return nil, err
+	}
+	c := new(big.Int).Lsh(x, 1)
+	switch {
+	case y.Cmp(yPos) == 0:
+	case y.Cmp(fpNeg(yPos)) == 0:
+		c.SetBit(c, 0, 1)
+	default:
+		return nil, ErrInvalidPoint
+	}
+	return c, nil
+}
+
+// decompressG1 is the contract's decompress_g1.
+func decompressG1(c *big.Int) (x, y *big.Int, err error) {
+	if c == nil || c.Sign() < 0 {
+		return nil, nil, ErrInvalidPoint
+	}
+	if c.Sign() == 0 {
+		return new(big.Int), new(big.Int), nil
+	}
+	negate := c.Bit(0) == 1
+	x = new(big.Int).Rsh(c, 1)
+	if !reduced(x) {
+		return nil, nil, ErrInvalidPoint
+	}
+	if y, err = sqrtFp(g1RHS(x)); err != nil {
+		return nil, nil, err
+	}
+	if negate {
+		y = fpNeg(y)
+	}
+	return x, y, nil
+}
+
+// compressG2 is the contract's compress_g2.
+func compressG2(x0, x1, y0, y1 *big.Int) (c0, c1 *big.Int, err error) {
+	if !reduced(x0, x1, y0, y1) {
+		return nil, nil, ErrInvalidPoint
+	}
+	if x0.Sign() == 0 && x1.Sign() == 0 && y0.Sign() == 0 && y1.Sign() == 0 {
+		return new(big.Int), new(big.Int), nil
+	}
+
+	y0Pos, y1Pos := g2RHS(x0, x1)
+	d, err := sqrtFp(fpAdd(fpMul(y0Pos, y0Pos), fpMul(y1Pos, y1Pos)))
+	if err != nil {
+		return nil, nil, err
+	}
+	hint := !isSquareFp(fpMul(fpAdd(y0Pos, d), half))
+	if y0Pos, y1Pos, err = sqrtFp2(y0Pos, y1Pos, hint); err != nil {
+		return nil, nil, err
+	}
+
+	c0 = new(big.Int).Lsh(x0, 2)
+	if hint {
+		c0.SetBit(c0, 1, 1)
+	}
+	switch {
+	case y0.Cmp(y0Pos) == 0 && y1.Cmp(y1Pos) == 0:
+	case y0.Cmp(fpNeg(y0Pos)) == 0 && y1.Cmp(fpNeg(y1Pos)) == 0:
+		c0.SetBit(c0, 0, 1)
+	default:
+		return nil, nil, ErrInvalidPoint
+	}
+	return c0, new(big.Int).Set(x1), nil
+}
+
+// decompressG2 is the contract's decompress_g2.
+func decompressG2(c0, c1 *big.Int) (x0, x1, y0, y1 *big.Int, err error) {
+	if c0 == nil || c1 == nil || c0.Sign() < 0 || c1.Sign() < 0 {
+		return nil, nil, nil, nil, ErrInvalidPoint
+	}
+	if c0.Sign() == 0 && c1.Sign() == 0 {
+		return new(big.Int), new(big.Int), new(big.Int), new(big.Int), nil
+	}
+	negate := c0.Bit(0) == 1
+	hint := c0.Bit(1) == 1
+	x0 = new(big.Int).Rsh(c0, 2)
+	x1 = new(big.Int).Set(c1)
+	if !reduced(x0, x1) {
+		return nil, nil, nil, nil, ErrInvalidPoint
+	}
+	y0, y1 = g2RHS(x0, x1)
+	if y0, y1, err = sqrtFp2(y0, y1, hint); err != nil {
+		return nil, nil, nil, nil, err
+	}
+	if negate {
+		y0, y1 = fpNeg(y0), fpNeg(y1)
+	}
+	return x0, x1, y0, y1, nil
+}
@@ -0,0 +1,197 @@
+package solidity
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/kanthub/zkid-zkp/internal/testcircuit"
+)
+
+// expectation is how the contract must relate to groth16.Verify in a case.
+type expectation int
+
+const (
+	// agree: the contract accepts exactly when gnark accepts.
+	agree expectation = iota
+	// contractRejects: the contract must reject whatever gnark does, e.g.
+	// for public inputs ≥ r, which gnark silently reduces.
+	contractRejects
+)
+
+// crossCase is the outcome of one differential check.
+type crossCase struct {
+	name     string
+	expect   expectation
+	contract error // nil if the contract logic accepts
+	gnark    error // nil if groth16.Verify accepts
+}
+
+func (c crossCase) ok() bool {
+	if c.expect == contractRejects {
+		return c.contract != nil
+	}
+	return (c.contract == nil) == (c.gnark == nil)
+}
+
+// TestCrossCheck runs the contract logic and groth16.Verify side by side on
+// a valid proof and on mutations of it: altered and non-canonical public
+// inputs, negated, unreduced and infinity points and flipped compression
+// bits.
+func TestCrossCheck(t *testing.T) {
+	f := newFixture(t)
+
+	// the contract is the one exported from the verifying key
+	v, err := ParseVerifier(f.contract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromVK, err := NewVerifier(f.vk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v.Equal(fromVK) {
+		t.Fatal("parsed contract constants differ from the verifying key")
+	}
+	if v.InputCount() != len(f.input) {
+		t.Fatalf("contract takes %d inputs, circuit has %d", v.InputCount(), len(f.input))
+	}
+
+	p, input := f.p, f.input
+	c, err := p.Compress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gnarkVerify(f.vk, p, input); err != nil {
+		t.Fatalf("proof does not verify with gnark: %v", err)
+	}
+
+	var cases []crossCase
+	uncompressed := func(name string, expect expectation, p Proof, in Input) {
+		cases = append(cases, crossCase{name, expect, v.VerifyProof(p, in), gnarkVerify(f.vk, p, in)})
+	}
+	compressed := func(name string, expect expectation, c CompressedProof, in Input) {
+		// gnark sees the points the contract would decompress to
+		gnarkErr := fmt.Errorf("not decompressible")
+		if d, err := c.Decompress(); err == nil {
+			gnarkErr = gnarkVerify(f.vk, d, in)
+		}
+		cases = append(cases, crossCase{name, expect, v.VerifyCompressedProof(c, in), gnarkErr})
+	}
+
+	uncompressed("valid proof", agree, p, input)
+	compressed("valid compressed proof", agree, c, input)
+
+	r := fr.Modulus()
+	for i := range input {
+		next := withInput(input, i, new(big.Int).Mod(new(big.Int).Add(input[i], big.NewInt(1)), r))
+		uncompressed(fmt.Sprintf("input[%d]+1", i), agree, p, next)
+		nonCanonical := withInput(input, i, new(big.Int).Add(input[i], r))
+		uncompressed(fmt.Sprintf("input[%d]+r (non-canonical)", i), contractRejects, p, nonCanonical)
+		compressed(fmt.Sprintf("compressed, input[%d]+r (non-canonical)", i), contractRejects, c, nonCanonical)
+	}
+
+	pMod := fp.Modulus()
+	negY := func(y *big.Int) *big.Int { return new(big.Int).Mod(new(big.Int).Neg(y), pMod) }
+	uncompressed("A negated", agree, withWords(p, map[int]*big.Int{1: negY(p[1])}), input)
+	uncompressed("B negated", agree, withWords(p, map[int]*big.Int{4: negY(p[4]), 5: negY(p[5])}), input)
+	uncompressed("C negated", agree, withWords(p, map[int]*big.Int{7: negY(p[7])}), input)
+	uncompressed("A and C swapped", agree, withWords(p, map[int]*big.Int{0: p[6], 1: p[7], 6: p[0], 7: p[1]}), input)
+	uncompressed("A at infinity", agree, withWords(p, map[int]*big.Int{0: new(big.Int), 1: new(big.Int)}), input)
+	uncompressed("A.y unreduced (+p)", contractRejects, withWords(p, map[int]*big.Int{1: new(big.Int).Add(p[1], pMod)}), input)
+	uncompressed("B coefficients swapped", agree, withWords(p, map[int]*big.Int{2: p[3], 3: p[2], 4: p[5], 5: p[4]}), input)
+
+	flip := func(x *big.Int, bit int) *big.Int {
+		return new(big.Int).SetBit(new(big.Int).Set(x), bit, x.Bit(bit)^1)
+	}
+	compressed("compressed A sign bit flipped", agree, withCompressed(c, 0, flip(c[0], 0)), input)
+	compressed("compressed C sign bit flipped", agree, withCompressed(c, 3, flip(c[3], 0)), input)
+	compressed("compressed B sign bit flipped", agree, withCompressed(c, 2, flip(c[2], 0)), input)
+	compressed("compressed B hint bit flipped", agree, withCompressed(c, 2, flip(c[2], 1)), input)
+	compressed("compressed A at infinity", agree, withCompressed(c, 0, new(big.Int)), input)
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if !tc.ok() {
+				t.Fatalf("contract: %v, gnark: %v", tc.contract, tc.gnark)
+			}
+		})
+	}
+
+	// the valid proof must actually be accepted, not merely agreed upon
+	if cases[0].contract != nil || cases[1].contract != nil {
+		t.Fatal("contract rejects the valid proof")
+	}
+}
+
+// TestCompression compresses and decompresses random points and their
+// negations with the contract's algorithms, and compares with the originals.
+func TestCompression(t *testing.T) {
+	_, _, g1, g2 := bn254.Generators()
+	for i := 0; i < 64; i++ {
+		k, err := rand.Int(rand.Reader, fr.Modulus())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var a bn254.G1Affine
+		var b bn254.G2Affine
+		a.ScalarMultiplication(&g1, k)
+		b.ScalarMultiplication(&g2, k)
+		if i%2 == 1 {
+			a.Neg(&a)
+			b.Neg(&b)
+		}
+
+		aw, bw := g1Words(&a), g2Words(&b)
+		ca, err := compressG1(aw[0], aw[1])
+		if err != nil {
+			t.Fatalf("G1 point %d: %v", i, err)
+		}
+		x, y, err := decompressG1(ca)
+		if err != nil || x.Cmp(aw[0]) != 0 || y.Cmp(aw[1]) != 0 {
+			t.Fatalf("G1 point %d does not round-trip", i)
+		}
+		c0, c1, err := compressG2(bw[0], bw[1], bw[2], bw[3])
+		if err != nil {
+			t.Fatalf("G2 point %d: %v", i, err)
+		}
+		x0, x1, y0, y1, err := decompressG2(c0, c1)
+		if err != nil || x0.Cmp(bw[0]) != 0 || x1.Cmp(bw[1]) != 0 || y0.Cmp(bw[2]) != 0 || y1.Cmp(bw[3]) != 0 {
+			t.Fatalf("G2 point %d does not round-trip", i)
+		}
+	}
+}
+
+// gnarkVerify runs groth16.Verify on the uint256 forms. Public inputs are
+// assigned as gnark does for *big.Int, i.e. reduced mod r.
+func gnarkVerify(vk groth16.VerifyingKey, p Proof, input Input) error {
+	proof, err := p.Groth16()
+	if err != nil {
+		return err
+	}
+	return testcircuit.Verify(vk, proof, input)
+}
+
+func withInput(in Input, i int, x *big.Int) Input {
+	out := append(Input(nil), in...)
+	out[i] = x
+	return out
+}
+
+func withWords(p Proof, words map[int]*big.Int) Proof {
+	for i, x := range words {
+		p[i] = x
+	}
+	return p
+}
+
+func withCompressed(c CompressedProof, i int, x *big.Int) CompressedProof {
+	c[i] = x
+	return c
+}
@@ -0,0 +1,286 @@
+// Proof encodings of the exported Solidity verifier.
+//
+// Verifier.sol (see setup_keys.ExportSolidity) takes a proof either as
+// uint256[8] — the points (A, B, C) in EIP-197 layout — or as uint256[4]
+// compressed points, produced by its compress_g1 / compress_g2:
+//
+//	uint256[8]: A.x, A.y, B.x1, B.x0, B.y1, B.y0, C.x, C.y
+//	uint256[4]: compress_g1(A), B.x1, compress_g2(B) c0, compress_g1(C)
+//
+// G2 coefficients are written imaginary part first, as the pairing
+// precompile expects. This package reproduces both layouts bit for bit so
+// that the result can be submitted without hand-copying.
+package solidity
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254/fp"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+
+	"github.com/kanthub/zkid-zkp/bundle"
+)
+
+// ErrInvalidPoint is returned for coordinates that are not reduced or not on
+// the curve, where the contract reverts with ProofInvalid.
+var ErrInvalidPoint = errors.New("invalid curve point")
+
+// Proof is the uint256[8] argument of verifyProof.
+type Proof [8]*big.Int
+
+// CompressedProof is the uint256[4] argument of verifyCompressedProof.
+type CompressedProof [4]*big.Int
+
+// FromGroth16 returns the uint256[8] form of a BN254 Groth16 proof.
+// Proofs with Pedersen commitments are rejected: the exported verifier has
+// no input for them.
+func FromGroth16(proof groth16.Proof) (Proof, error) {
+	p, ok := proof.(*groth16_bn254.Proof)
+	if !ok {
+		return Proof{}, fmt.Errorf("expected a BN254 proof, got %T", proof)
+	}
+	if len(p.Commitments) != 0 {
+		return Proof{}, fmt.Errorf("proof has %d commitments, the Solidity verifier takes none", len(p.Commitments))
+	}
+	return Proof{
+		p.Ar.X.BigInt(new(big.Int)),
+		p.Ar.Y.BigInt(new(big.Int)),
+		p.Bs.X.A1.BigInt(new(big.Int)),
+		p.Bs.X.A0.BigInt(new(big.Int)),
+		p.Bs.Y.A1.BigInt(new(big.Int)),
+		p.Bs.Y.A0.BigInt(new(big.Int)),
+		p.Krs.X.BigInt(new(big.Int)),
+		p.Krs.Y.BigInt(new(big.Int)),
+	}, nil
+}
+
+// Groth16 converts the uint256[8] form back into a gnark proof. The points
+// are checked to be on their curves and in the right subgroups.
+func (p Proof) Groth16() (groth16.Proof, error) {
+	var out groth16_bn254.Proof
+	for i, x := range p {
+		if x == nil || x.Sign() < 0 || x.Cmp(fp.Modulus()) >= 0 {
+			return nil, fmt.Errorf("proof[%d]: coordinate not in [0, p): %w", i, ErrInvalidPoint)
+		}
+	}
+	out.Ar.X.SetBigInt(p[0])
+	out.Ar.Y.SetBigInt(p[1])
+	out.Bs.X.A1.SetBigInt(p[2])
+	out.Bs.X.A0.SetBigInt(p[3])
+	out.Bs.Y.A1.SetBigInt(p[4])
+	out.Bs.Y.A0.SetBigInt(p[5])
+	out.Krs.X.SetBigInt(p[6])
+	out.Krs.Y.SetBigInt(p[7])
+	if !out.Ar.IsInSubGroup() || !out.Bs.IsInSubGroup() || !out.Krs.IsInSubGroup() {
+		return nil, ErrInvalidPoint
+	}
+	return &out, nil
+}
+
+// Compress mirrors the contract's compressProof.
+func (p Proof) Compress() (CompressedProof, error) {
+	var c CompressedProof
+	var err error
+	if c[0], err = compressG1(p[0], p[1]); err != nil {
+		return CompressedProof{}, fmt.Errorf("A: %w", err)
+	}
+	if c[2], c[1], err = compressG2(p[3], p[2], p[5], p[4]); err != nil {
+		return CompressedProof{}, fmt.Errorf("B: %w", err)
+	}
+	if c[3], err = compressG1(p[6], p[7]); err != nil {
+		return CompressedProof{}, fmt.Errorf("C: %w", err)
+	}
+	return c, nil
+}
+
+// Decompress mirrors the point decompression of verifyCompressedProof.
+func (c CompressedProof) Decompress() (Proof, error) {
+	var p Proof
+	var err error
+	if p[0], p[1], err = decompressG1(c[0]); err != nil {
+		return Proof{}, fmt.Errorf("A: %w", err)
+	}
+	if p[3], p[2], p[5], p[4], err = decompressG2(c[2], c[1]); err != nil {
+		return Proof{}, fmt.Errorf("B: %w", err)
+	}
+	if p[6], p[7], err = decompressG1(c[3]); err != nil {
+		return Proof{}, fmt.Errorf("C: %w", err)
+	}
+	return p, nil
+}
+
+// Bytes returns the 256-byte ABI encoding of uint256[8].
+func (p Proof) Bytes() []byte {
+	return words(p[:])
+}
+
+// Hex returns Bytes as 0x-prefixed hex.
+func (p Proof) Hex() string {
+	return "0x" + hex.EncodeToString(p.Bytes())
+}
+
+// Bytes returns the 128-byte ABI encoding of uint256[4].
+func (c CompressedProof) Bytes() []byte {
+	return words(c[:])
+}
+
+// Hex returns Bytes as 0x-prefixed hex.
+func (c CompressedProof) Hex() string {
+	return "0x" + hex.EncodeToString(c.Bytes())
+}
+
+// MarshalJSON encodes the proof as an array of 0x-prefixed uint256 words,
+// the form ethers.js and web3.js accept for uint256[8].
+func (p Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexWords(p[:]))
+}
+
+// UnmarshalJSON decodes an array of 8 decimal or 0x-prefixed integers.
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	return unmarshalWords(data, p[:])
+}
+
+// MarshalJSON encodes the compressed proof as 0x-prefixed uint256 words.
+func (c CompressedProof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexWords(c[:]))
+}
+
+// UnmarshalJSON decodes an array of 4 decimal or 0x-prefixed integers.
+func (c *CompressedProof) UnmarshalJSON(data []byte) error {
+	return unmarshalWords(data, c[:])
+}
+
+// ParseProofHex decodes the 256-byte hex form returned by Proof.Hex.
+func ParseProofHex(s string) (Proof, error) {
+	var p Proof
+	if err := parseHexWords(s, p[:]); err != nil {
+		return Proof{}, err
+	}
+	return p, nil
+}
+
+// ParseCompressedHex decodes the 128-byte hex form returned by CompressedProof.Hex.
+func ParseCompressedHex(s string) (CompressedProof, error) {
+	var c CompressedProof
+	if err := parseHexWords(s, c[:]); err != nil {
+		return CompressedProof{}, err
+	}
+	return c, nil
+}
+
+// words concatenates xs as 32-byte big-endian words.
+func words(xs []*big.Int) []byte {
+	out := make([]byte, 0, 32*len(xs))
+	for _, x := range xs {
+		out = append(out, x.FillBytes(make([]byte, 32))...)
+	}
+	return out
+}
+
+func hexWords(xs []*big.Int) []string {
+	out := make([]string, len(xs))
+	for i, x := range xs {
+		out[i] = "0x" + hex.EncodeToString(x.FillBytes(make([]byte, 32)))
+	}
+	return out
+}
+
+func unmarshalWords(data []byte, dst []*big.Int) error {
+	var raw []string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) != len(dst) {
+		return fmt.Errorf("expected %d words, got %d", len(dst), len(raw))
+	}
+	for i, s := range raw {
+		x, ok := new(big.Int).SetString(s, 0)
+		if !ok || x.Sign() < 0 || x.BitLen() > 256 {
+			return fmt.Errorf("word %d: invalid uint256 %q", i, s)
+		}
+		dst[i] = x
+	}
+	return nil
+}
+
+func parseHexWords(s string, dst []*big.Int) error {
+	raw, err := hex.DecodeString(strings.TrimPrefix(s, "0x"))
+	if err != nil {
+		return err
+	}
+	if len(raw) != 32*len(dst) {
+		return fmt.Errorf("expected %d bytes, got %d", 32*len(dst), len(raw))
+	}
+	for i := range dst {
+		dst[i] = new(big.Int).SetBytes(raw[32*i : 32*(i+1)])
+	}
+	return nil
+}
+
+// Input is the uint256[N] public input argument, in circuit order.
+type Input []*big.Int
+
+// MarshalJSON encodes the inputs as 0x-prefixed uint256 words.
+func (in Input) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hexWords(in))
+}
+
+// UnmarshalJSON decodes an array of decimal or 0x-prefixed integers.
+func (in *Input) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	out := make(Input, len(raw))
+	if err := unmarshalWords(data, out); err != nil {
+		return err
+	}
+	*in = out
+	return nil
+}
+
+// Export is what a front-end submits to the verifier contract: both proof
+// forms and the public inputs, so either verifyProof or
+// verifyCompressedProof can be called directly, plus the ready-made
+// calldata of both calls for relayers.
+type Export struct {
+	Proof              Proof           `json:"proof"`
+	CompressedProof    CompressedProof `json:"compressed_proof"`
+	Input              Input           `json:"input"`
+	Calldata           string          `json:"calldata"`
+	CompressedCalldata string          `json:"compressed_calldata"`
+}
+
+// NewExport encodes a proof bundle for the Solidity verifier.
+func NewExport(b *bundle.Bundle) (*Export, error) {
+	p, err := FromGroth16(b.Proof)
+	if err != nil {
+		return nil, err
+	}
+	c, err := p.Compress()
+	if err != nil {
+		return nil, err
+	}
+	input := Input(b.PublicInputs)
+	call, err := VerifyProofCalldata(p, input)
+	if err != nil {
+		return nil, err
+	}
+	compressedCall, err := VerifyCompressedProofCalldata(c, input)
+	if err != nil {
+		return nil, err
+	}
+	return &Export{
+		Proof:              p,
+		CompressedProof:    c,
+		Input:              input,
+		Calldata:           "0x" + hex.EncodeToString(call),
+		CompressedCalldata: "0x" + hex.EncodeToString(compressedCall),
+	}, nil
+}
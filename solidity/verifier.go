@@ -0,0 +1,303 @@
+// Pure-Go port of the verification path of Verifier.sol.
+//
+// Verifier works on the same uint256 arrays as the contract and follows it
+// step by step: reduced-input checks, publicInputMSM with the ECMUL/ECADD
+// precompile semantics, point decompression and the final pairing check.
+// It exists to cross-check, without an EVM, that the contract accepts
+// exactly the proofs groth16.Verify accepts (see crosscheck_test.go).
+package solidity
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+
+	"github.com/consensys/gnark-crypto/ecc/bn254"
+	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
+	"github.com/consensys/gnark/backend/groth16"
+	groth16_bn254 "github.com/consensys/gnark/backend/groth16/bn254"
+)
+
+// Errors mirroring the custom errors the contract reverts with.
+var (
+	ErrProofInvalid          = errors.New("ProofInvalid")
+	ErrPublicInputNotInField = errors.New("PublicInputNotInField")
+)
+
+// Verifier holds the constants of one exported contract. G2 points are
+// stored as the contract names them: X_0, X_1, Y_0, Y_1 (real part first).
+type Verifier struct {
+	Alpha    [2]*big.Int
+	BetaNeg  [4]*big.Int
+	GammaNeg [4]*big.Int
+	DeltaNeg [4]*big.Int
+	Constant [2]*big.Int
+	Pub      [][2]*big.Int
+}
+
+// NewVerifier computes the contract constants from a BN254 VerifyingKey,
+// the way setup_keys.ExportSolidity fills in the template.
+func NewVerifier(vk groth16.VerifyingKey) (*Verifier, error) {
+	key, ok := vk.(*groth16_bn254.VerifyingKey)
+	if !ok {
+		return nil, fmt.Errorf("expected a BN254 verifying key, got %T", vk)
+	}
+	if len(key.PublicAndCommitmentCommitted) != 0 {
+		return nil, fmt.Errorf("verifying key has commitments, the Solidity verifier takes none")
+	}
+	if len(key.G1.K) == 0 {
+		return nil, fmt.Errorf("verifying key has no K points")
+	}
+
+	var betaNeg, gammaNeg, deltaNeg bn254.G2Affine
+	betaNeg.Neg(&key.G2.Beta)
+	gammaNeg.Neg(&key.G2.Gamma)
+	deltaNeg.Neg(&key.G2.Delta)
+
+	v := &Verifier{
+		Alpha:    g1Words(&key.G1.Alpha),
+		BetaNeg:  g2Words(&betaNeg),
+		GammaNeg: g2Words(&gammaNeg),
+		DeltaNeg: g2Words(&deltaNeg),
+		Constant: g1Words(&key.G1.K[0]),
+	}
+	for i := 1; i < len(key.G1.K); i++ {
+		v.Pub = append(v.Pub, g1Words(&key.G1.K[i]))
+	}
+	return v, nil
+}
+
+var solidityConstant = regexp.MustCompile(`uint256\s+constant\s+(\w+)\s*=\s*(0[xX][0-9a-fA-F]+|[0-9]+)\s*;`)
+
+// ParseVerifier reads the constants of an exported Verifier.sol, so that the
+// contract actually deployed can be checked against the VerifyingKey.
+func ParseVerifier(src []byte) (*Verifier, error) {
+	consts := map[string]*big.Int{}
+	for _, m := range solidityConstant.FindAllSubmatch(src, -1) {
+		x, ok := new(big.Int).SetString(string(m[2]), 0)
+		if !ok {
+			return nil, fmt.Errorf("constant %s: invalid integer", m[1])
+		}
+		consts[string(m[1])] = x
+	}
+	get := func(names ...string) ([]*big.Int, error) {
+		out := make([]*big.Int, len(names))
+		for i, name := range names {
+			x, ok := consts[name]
+			if !ok {
+				return nil, fmt.Errorf("constant %s not found", name)
+			}
+			out[i] = x
+		}
+		return out, nil
+	}
+	g2 := func(prefix string) ([4]*big.Int, error) {
+		xs, err := get(prefix+"_X_0", prefix+"_X_1", prefix+"_Y_0", prefix+"_Y_1")
+		if err != nil {
+			return [4]*big.Int{}, err
+		}
+		return [4]*big.Int{xs[0], xs[1], xs[2], xs[3]}, nil
+	}
+
+	var v Verifier
+	xs, err := get("ALPHA_X", "ALPHA_Y", "CONSTANT_X", "CONSTANT_Y")
+	if err != nil {
+		return nil, err
+	}
+	v.Alpha = [2]*big.Int{xs[0], xs[1]}
+	v.Constant = [2]*big.Int{xs[2], xs[3]}
+	if v.BetaNeg, err = g2("BETA_NEG"); err != nil {
+		return nil, err
+	}
+	if v.GammaNeg, err = g2("GAMMA_NEG"); err != nil {
+		return nil, err
+	}
+	if v.DeltaNeg, err = g2("DELTA_NEG"); err != nil {
+		return nil, err
+	}
+	for i := 0; ; i++ {
+		x, okX := consts[fmt.Sprintf("PUB_%d_X", i)]
+		y, okY := consts[fmt.Sprintf("PUB_%d_Y", i)]
+		if !okX || !okY {
+			break
+		}
+		v.Pub = append(v.Pub, [2]*big.Int{x, y})
+	}
+	if len(v.Pub) == 0 {
+		return nil, fmt.Errorf("no PUB_i constants found")
+	}
+	return &v, nil
+}
+
+// Equal reports whether two verifiers have the same constants.
+func (v *Verifier) Equal(o *Verifier) bool {
+	eq := func(a, b []*big.Int) bool {
+		for i := range a {
+			if a[i].Cmp(b[i]) != 0 {
+				return false
+			}
+		}
+		return true
+	}
+	if !eq(v.Alpha[:], o.Alpha[:]) || !eq(v.Constant[:], o.Constant[:]) ||
+		!eq(v.BetaNeg[:], o.BetaNeg[:]) || !eq(v.GammaNeg[:], o.GammaNeg[:]) ||
+		!eq(v.DeltaNeg[:], o.DeltaNeg[:]) || len(v.Pub) != len(o.Pub) {
+		return false
+	}
+	for i := range v.Pub {
+		if !eq(v.Pub[i][:], o.Pub[i][:]) {
+			return false
+		}
+	}
+	return true
+}
+
+// InputCount is N in uint256[N] input.
+func (v *Verifier) InputCount() int {
+	return len(v.Pub)
+}
+
+// VerifyProof mirrors verifyProof(uint256[8], uint256[N]): nil where the
+// contract returns, an error wrapping ErrProofInvalid or
+// ErrPublicInputNotInField where it reverts.
+func (v *Verifier) VerifyProof(p Proof, input Input) error {
+	lx, ly, err := v.publicInputMSM(input)
+	if err != nil {
+		return err
+	}
+	// the proof words are copied to the precompile as they are
+	return v.pairing([2]*big.Int{p[0], p[1]}, [4]*big.Int{p[3], p[2], p[5], p[4]}, [2]*big.Int{p[6], p[7]}, lx, ly)
+}
+
+// VerifyCompressedProof mirrors verifyCompressedProof(uint256[4], uint256[N]).
+func (v *Verifier) VerifyCompressedProof(c CompressedProof, input Input) error {
+	ax, ay, err := decompressG1(c[0])
+	if err != nil {
+		return fmt.Errorf("%w: A: %v", ErrProofInvalid, err)
+	}
+	bx0, bx1, by0, by1, err := decompressG2(c[2], c[1])
+	if err != nil {
+		return fmt.Errorf("%w: B: %v", ErrProofInvalid, err)
+	}
+	cx, cy, err := decompressG1(c[3])
+	if err != nil {
+		return fmt.Errorf("%w: C: %v", ErrProofInvalid, err)
+	}
+	lx, ly, err := v.publicInputMSM(input)
+	if err != nil {
+		return err
+	}
+	return v.pairing([2]*big.Int{ax, ay}, [4]*big.Int{bx0, bx1, by0, by1}, [2]*big.Int{cx, cy}, lx, ly)
+}
+
+// CompressProof mirrors the contract's compressProof view function.
+func (v *Verifier) CompressProof(p Proof) (CompressedProof, error) {
+	c, err := p.Compress()
+	if err != nil {
+		return CompressedProof{}, fmt.Errorf("%w: %v", ErrProofInvalid, err)
+	}
+	return c, nil
+}
+
+// publicInputMSM computes CONSTANT + Σ input[i]·PUB_i with the precompile
+// semantics: scalars are not reduced, so any input ≥ r is rejected.
+func (v *Verifier) publicInputMSM(input Input) (x, y *big.Int, err error) {
+	if len(input) != len(v.Pub) {
+		return nil, nil, fmt.Errorf("contract takes uint256[%d] input, got %d values", len(v.Pub), len(input))
+	}
+	acc, err := g1Point(v.Constant[0], v.Constant[1])
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: CONSTANT: %v", ErrPublicInputNotInField, err)
+	}
+	var sum bn254.G1Jac
+	sum.FromAffine(&acc)
+	for i, s := range input {
+		if s == nil || s.Sign() < 0 || s.BitLen() > 256 {
+			return nil, nil, fmt.Errorf("input[%d] is not a uint256", i)
+		}
+		if s.Cmp(fr.Modulus()) >= 0 {
+			return nil, nil, fmt.Errorf("%w: input[%d]", ErrPublicInputNotInField, i)
+		}
+		pub, err := g1Point(v.Pub[i][0], v.Pub[i][1])
+		if err != nil {
+			return nil, nil, fmt.Errorf("%w: PUB_%d: %v", ErrPublicInputNotInField, i, err)
+		}
+		var term bn254.G1Jac
+		term.FromAffine(&pub)
+		term.ScalarMultiplication(&term, s)
+		sum.AddAssign(&term)
+	}
+	var out bn254.G1Affine
+	out.FromJacobian(&sum)
+	w := g1Words(&out)
+	return w[0], w[1], nil
+}
+
+// pairing checks e(A, B)·e(C, -δ)·e(α, -β)·e(L, -γ) = 1 with the pairing
+// precompile's input validation. G2 points are given as (x0, x1, y0, y1).
+func (v *Verifier) pairing(a [2]*big.Int, b [4]*big.Int, c [2]*big.Int, lx, ly *big.Int) error {
+	g1s := [][2]*big.Int{a, c, v.Alpha, {lx, ly}}
+	g2s := [][4]*big.Int{b, v.DeltaNeg, v.BetaNeg, v.GammaNeg}
+	P := make([]bn254.G1Affine, len(g1s))
+	Q := make([]bn254.G2Affine, len(g2s))
+	for i := range g1s {
+		var err error
+		if P[i], err = g1Point(g1s[i][0], g1s[i][1]); err != nil {
+			return fmt.Errorf("%w: pairing input %d: %v", ErrProofInvalid, i, err)
+		}
+		if Q[i], err = g2Point(g2s[i]); err != nil {
+			return fmt.Errorf("%w: pairing input %d: %v", ErrProofInvalid, i, err)
+		}
+	}
+	ok, err := bn254.PairingCheck(P, Q)
+	if err != nil || !ok {
+		return fmt.Errorf("%w: pairing check failed", ErrProofInvalid)
+	}
+	return nil
+}
+
+// g1Point validates a G1 point as the ECADD/ECMUL/pairing precompiles do:
+// reduced coordinates on the curve, (0, 0) being the point at infinity.
+func g1Point(x, y *big.Int) (bn254.G1Affine, error) {
+	var p bn254.G1Affine
+	if !reduced(x, y) {
+		return p, fmt.Errorf("G1 coordinate not reduced")
+	}
+	p.X.SetBigInt(x)
+	p.Y.SetBigInt(y)
+	if !p.IsInfinity() && !p.IsOnCurve() {
+		return p, fmt.Errorf("G1 point not on curve")
+	}
+	return p, nil
+}
+
+// g2Point validates a G2 point as the pairing precompile does: reduced
+// coefficients, on the twist and in the r-torsion subgroup.
+func g2Point(w [4]*big.Int) (bn254.G2Affine, error) {
+	var q bn254.G2Affine
+	if !reduced(w[:]...) {
+		return q, fmt.Errorf("G2 coefficient not reduced")
+	}
+	q.X.A0.SetBigInt(w[0])
+	q.X.A1.SetBigInt(w[1])
+	q.Y.A0.SetBigInt(w[2])
+	q.Y.A1.SetBigInt(w[3])
+	if !q.IsInfinity() && (!q.IsOnCurve() || !q.IsInSubGroup()) {
+		return q, fmt.Errorf("G2 point not in the subgroup")
+	}
+	return q, nil
+}
+
+func g1Words(p *bn254.G1Affine) [2]*big.Int {
+	return [2]*big.Int{p.X.BigInt(new(big.Int)), p.Y.BigInt(new(big.Int))}
+}
+
+func g2Words(q *bn254.G2Affine) [4]*big.Int {
+	return [4]*big.Int{
+		q.X.A0.BigInt(new(big.Int)),
+		q.X.A1.BigInt(new(big.Int)),
+		q.Y.A0.BigInt(new(big.Int)),
+		q.Y.A1.BigInt(new(big.Int)),
+	}
+}
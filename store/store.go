@@ -0,0 +1,309 @@
+// Versioned artifact store for compiled circuits, keys and verifiers.
+//
+// Artifacts of one policy version live in their own directory:
+//
+//	<root>/policy-<PolicyID>/v<Version>/
+//	    circuit.r1cs     compiled constraint system
+//	    proving.key      Groth16 ProvingKey (uncompressed, for fast loading)
+//	    verifying.key    Groth16 VerifyingKey
+//	    Verifier.sol     Solidity verifier exported from the VerifyingKey
+//	    manifest.json    SHA-256 of every file, constraint counts, gnark version
+//
+// A version directory is written to a staging directory first and renamed
+// into place once complete, so readers never observe a partial set. Existing
+// versions are never overwritten; upgrades use a new policy Version.
+package store
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/consensys/gnark"
+	"github.com/consensys/gnark-crypto/ecc"
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+)
+
+const (
+	CircuitFile      = "circuit.r1cs"
+	ProvingKeyFile   = "proving.key"
+	VerifyingKeyFile = "verifying.key"
+	SolidityFile     = "Verifier.sol"
+	ManifestFile     = "manifest.json"
+)
+
+var (
+	// ErrExists is returned when saving a (PolicyID, Version) that is already stored.
+	ErrExists = errors.New("artifacts already exist")
+	// ErrNotFound is returned when a (PolicyID, Version) is not stored.
+	ErrNotFound = errors.New("artifacts not found")
+	// ErrIntegrity is returned when a file does not match its manifest hash.
+	ErrIntegrity = errors.New("artifact hash mismatch")
+)
+
+// FileInfo records one artifact file in the manifest.
+type FileInfo struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// Manifest describes the artifacts of one policy version.
+type Manifest struct {
+	PolicyID     int64               `json:"policy_id"`
+	Version      int64               `json:"version"`
+	PolicyName   string              `json:"policy_name"`
+	Predicate    string              `json:"predicate"`
+	Mode         string              `json:"mode"`
+	Curve        string              `json:"curve"`
+	Backend      string              `json:"backend"`
+	GnarkVersion string              `json:"gnark_version"`
+	Constraints  int                 `json:"constraints"`
+	PublicVars   int                 `json:"public_variables"`
+	SecretVars   int                 `json:"secret_variables"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Files        map[string]FileInfo `json:"files"`
+}
+
+// Store is an artifact directory.
+type Store struct {
+	root string
+}
+
+// Open returns the store rooted at root, creating the directory if needed.
+func Open(root string) (*Store, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create artifact root: %w", err)
+	}
+	return &Store{root: root}, nil
+}
+
+// Root returns the root directory of the store.
+func (s *Store) Root() string {
+	return s.root
+}
+
+// Dir returns the directory of one policy version.
+func (s *Store) Dir(policyID, version int64) string {
+	return filepath.Join(s.root, fmt.Sprintf("policy-%d", policyID), fmt.Sprintf("v%d", version))
+}
+
+// Path returns the path of an artifact file of one policy version.
+func (s *Store) Path(policyID, version int64, name string) string {
+	return filepath.Join(s.Dir(policyID, version), name)
+}
+
+// Save writes the full artifact set of a policy version atomically.
+func (s *Store) Save(
+	policy circuits.Policy,
+	cs constraint.ConstraintSystem,
+	pk groth16.ProvingKey,
+	vk groth16.VerifyingKey,
+) (*Manifest, error) {
+	final := s.Dir(policy.ID, policy.Version)
+	if _, err := os.Stat(final); err == nil {
+		return nil, fmt.Errorf("policy %d v%d: %w", policy.ID, policy.Version, ErrExists)
+	}
+	parent := filepath.Dir(final)
+	if err := os.MkdirAll(parent, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create policy dir: %w", err)
+	}
+	staging, err := os.MkdirTemp(parent, ".staging-"+filepath.Base(final)+"-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging dir: %w", err)
+	}
+	defer os.RemoveAll(staging) // no-op after a successful rename
+
+	m := &Manifest{
+		PolicyID:     policy.ID,
+		Version:      policy.Version,
+		PolicyName:   policy.Name,
+		Predicate:    policy.Predicate.String(),
+		Mode:         policy.Mode.String(),
+		Curve:        ecc.BN254.String(),
+		Backend:      "groth16",
+		GnarkVersion: gnark.Version.String(),
+		Constraints:  cs.GetNbConstraints(),
+		PublicVars:   cs.GetNbPublicVariables(),
+		SecretVars:   cs.GetNbSecretVariables(),
+		CreatedAt:    time.Now().UTC(),
+		Files:        map[string]FileInfo{},
+	}
+
+	writers := []struct {
+		name  string
+		write func(io.Writer) error
+	}{
+		{CircuitFile, func(w io.Writer) error { _, err := cs.WriteTo(w); return err }},
+		{ProvingKeyFile, func(w io.Writer) error { _, err := pk.WriteRawTo(w); return err }},
+		{VerifyingKeyFile, func(w io.Writer) error { _, err := vk.WriteTo(w); return err }},
+		{SolidityFile, func(w io.Writer) error { return vk.ExportSolidity(w) }},
+	}
+	for _, f := range writers {
+		info, err := writeFile(filepath.Join(staging, f.name), f.write)
+		if err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", f.name, err)
+		}
+		m.Files[f.name] = info
+	}
+
+	if _, err := writeFile(filepath.Join(staging, ManifestFile), func(w io.Writer) error {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		return enc.Encode(m)
+	}); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := os.Rename(staging, final); err != nil {
+		return nil, fmt.Errorf("failed to publish artifacts: %w", err)
+	}
+	return m, nil
+}
+
+// Manifest reads the manifest of one policy version.
+func (s *Store) Manifest(policyID, version int64) (*Manifest, error) {
+	data, err := os.ReadFile(s.Path(policyID, version, ManifestFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("policy %d v%d: %w", policyID, version, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+// List returns the manifests of all stored policy versions.
+func (s *Store) List() ([]*Manifest, error) {
+	paths, err := filepath.Glob(filepath.Join(s.root, "policy-*", "v*", ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var out []*Manifest
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, err
+		}
+		var m Manifest
+		if err := json.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", p, err)
+		}
+		out = append(out, &m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].PolicyID != out[j].PolicyID {
+			return out[i].PolicyID < out[j].PolicyID
+		}
+		return out[i].Version < out[j].Version
+	})
+	return out, nil
+}
+
+// LoadConstraintSystem reads the compiled circuit of a policy version. Its
+// hash must match the one recorded when the keys were generated, so a prover
+// never pairs a ProvingKey with a different circuit. This replaces a call to
+// frontend.Compile on every proof.
+func (s *Store) LoadConstraintSystem(policyID, version int64) (constraint.ConstraintSystem, error) {
+	cs := groth16.NewCS(ecc.BN254)
+	if err := s.load(policyID, version, CircuitFile, cs.ReadFrom); err != nil {
+		return nil, err
+	}
+	return cs, nil
+}
+
+// LoadProvingKey reads the ProvingKey of a policy version. The file is
+// hash-checked against the manifest before it is decoded, which lets the
+// decoder take gnark's unchecked fast path (no point decompression or
+// subgroup checks).
+func (s *Store) LoadProvingKey(policyID, version int64) (groth16.ProvingKey, error) {
+	pk := groth16.NewProvingKey(ecc.BN254)
+	if err := s.load(policyID, version, ProvingKeyFile, pk.UnsafeReadFrom); err != nil {
+		return nil, err
+	}
+	return pk, nil
+}
+
+// LoadVerifyingKey reads and hash-checks the VerifyingKey of a policy version.
+func (s *Store) LoadVerifyingKey(policyID, version int64) (groth16.VerifyingKey, error) {
+	vk := groth16.NewVerifyingKey(ecc.BN254)
+	if err := s.load(policyID, version, VerifyingKeyFile, vk.ReadFrom); err != nil {
+		return nil, err
+	}
+	return vk, nil
+}
+
+// load reads an artifact into memory once, checks its hash against the
+// manifest and only then decodes the verified bytes. Hashing the file and
+// reopening it to decode would let it be swapped in between, which matters
+// because the ProvingKey is decoded without checks.
+func (s *Store) load(policyID, version int64, name string, read func(io.Reader) (int64, error)) error {
+	m, err := s.Manifest(policyID, version)
+	if err != nil {
+		return err
+	}
+	want, ok := m.Files[name]
+	if !ok {
+		return fmt.Errorf("%s not listed in manifest: %w", name, ErrNotFound)
+	}
+	data, err := os.ReadFile(s.Path(policyID, version, name))
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != want.SHA256 {
+		return fmt.Errorf("%s: %w", name, ErrIntegrity)
+	}
+	if _, err := read(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return nil
+}
+
+// writeFile writes, fsyncs and hashes one file.
+func writeFile(path string, write func(io.Writer) error) (FileInfo, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o644)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	cw := &countingWriter{w: io.MultiWriter(f, h)}
+	if err := write(cw); err != nil {
+		return FileInfo{}, err
+	}
+	if err := f.Sync(); err != nil {
+		return FileInfo{}, err
+	}
+	if err := f.Close(); err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{SHA256: hex.EncodeToString(h.Sum(nil)), Size: cw.n}, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -1,163 +1,337 @@
-// Simulate the user's on-chain verification process: the user provides (1) public inputs and (2) the proof
-// The on-chain contract verifies using a hardcoded vk
+// Simulate the relying party's verification process: the user provides (1) public inputs and (2) the proof
+// The verifier only needs the verifying key; it never sees the holder's private attributes.
+// A valid proof shows that C was signed by an issuer of the set whose root is
+// among its public inputs; whether that set is trusted is up to the caller
+// (see zkid.Verifier).
 package verify_age
 
 import (
+	"errors"
 	"fmt"
-	"log"
 	"math/big"
-	"os"
 
-	"github.com/consensys/gnark-crypto/ecc"
 	"github.com/consensys/gnark-crypto/ecc/bn254/fr"
 	"github.com/consensys/gnark/backend/groth16"
 	"github.com/consensys/gnark/frontend"
-	"golang.org/x/crypto/sha3"
 
+	"github.com/kanthub/zkid-zkp/bundle"
 	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/encoding"
 )
 
-func VerifyProof(
-	policyID, version, threshold int64,
-	name, nation, address string,
-	age, identityID int64,
-	attrValue []byte,
-	did *big.Int,
-	C *big.Int, // commitment
-	vk groth16.VerifyingKey,
-) {
-	log.Println("Running off-chain verification...")
+var (
+	// ErrBadProof is returned when a proof does not verify against its public inputs.
+	ErrBadProof = errors.New("bad proof")
 
-	// 1) Compile the circuit (same as proving)
-	// var circuit circuits.Circuit
-	field := fr.Modulus()
-	// cs, err := frontend.Compile(field, r1cs.NewBuilder, &circuit)
-	// if err != nil {
-	// 	log.Fatalf("compile failed: %v", err)
-	// }
-	// _, vk, err := groth16.Setup(cs)
-	// if err != nil {
-	// 	log.Fatalf("Setup failed: %v", err)
-	// }
-	// Do NOT re-run Setup to regenerate vk, because it will be different
+	// ErrBounds is returned for a proof whose public Threshold or UpperBound
+	// is not the one the verifier requires.
+	ErrBounds = errors.New("proof bounds do not match the verifier's")
+)
 
-	// 2) Recompute witness (same as proving)
-	assignment, err := NewAssignmentCircuit(
-		policyID, version, threshold,
-		name, nation, address,
-		age, identityID,
-		attrValue, did, C,
-	)
-	if err != nil {
-		log.Fatalf("assignment error: %v", err)
+// Bounds are the public predicate bounds a verifier requires. The prover
+// picks the bounds it proves against, so they must be compared: otherwise a
+// proof of "age ≥ 0" passes a verifier that meant "age ≥ 18".
+type Bounds struct {
+	Threshold  *big.Int
+	UpperBound *big.Int // only used by range predicates, nil for 0
+}
+
+// Check returns an error wrapping ErrBounds unless public was proven against
+// exactly b.
+func (b Bounds) Check(public PublicInputs) error {
+	if b.Threshold == nil {
+		return fmt.Errorf("%w: verifier has no threshold", ErrBounds)
+	}
+	upper := b.UpperBound
+	if upper == nil {
+		upper = big.NewInt(0)
+	}
+	if public.Threshold == nil || public.Threshold.Cmp(b.Threshold) != 0 {
+		return fmt.Errorf("%w: proof threshold %v, verifier requires %s", ErrBounds, public.Threshold, b.Threshold)
+	}
+	if public.UpperBound == nil || public.UpperBound.Cmp(upper) != 0 {
+		return fmt.Errorf("%w: proof upper bound %v, verifier requires %s", ErrBounds, public.UpperBound, upper)
 	}
+	return nil
+}
+
+// publicInputCount is the number of public inputs of circuits.Circuit and
+// circuits.MembershipCircuit, rateLimitInputCount that of
+// circuits.RateLimitCircuit.
+const (
+	publicInputCount    = 8
+	rateLimitInputCount = 10
+)
+
+// PublicInputs are the public inputs of circuits.Circuit, in circuit order.
+// For a membership- or rate-limit-mode policy C is nil and CommitmentsRoot
+// takes its place (circuits.MembershipCircuit). For a rate-limit-mode policy
+// Scope is nil as well and Epoch, MessageIndex and MessageLimit take its
+// place (circuits.RateLimitCircuit).
+type PublicInputs struct {
+	PolicyID        int64
+	Version         int64
+	C               *big.Int // commitment
+	CommitmentsRoot *big.Int // root of the issued commitments, membership and rate-limit modes
+	Threshold       *big.Int
+	UpperBound      *big.Int // only used by range predicates, 0 otherwise
+	IssuersRoot     *big.Int // Merkle root of the trusted issuer set, see issuer.Set
+	Scope           *big.Int // verifier-chosen nullifier scope, 0 for none
+	Epoch           *big.Int // rate-limit mode only, see nullifier.Epoch
+	MessageIndex    *big.Int // rate-limit mode only
+	MessageLimit    *big.Int // rate-limit mode only
+	Nullifier       *big.Int // holder's nullifier in Scope (0 for scope 0) or of MessageIndex in Epoch
+}
+
+// LoadProof reads a proof bundle written by bundle.Save, in either
+// encoding.
+func LoadProof(path string) (*bundle.Bundle, error) {
+	return bundle.Load(path)
+}
 
-	// Public input C
-	assignment.C = C
+// PublicInputsOf extracts the public inputs of a bundle and checks that they
+// agree with its PolicyID and Version header.
+func PublicInputsOf(b *bundle.Bundle) (PublicInputs, error) {
+	public, err := PublicInputsFromVector(b.PublicInputs)
+	if err != nil {
+		return PublicInputs{}, err
+	}
+	if public.PolicyID != b.PolicyID || public.Version != b.Version {
+		return PublicInputs{}, fmt.Errorf("%w: bundle header does not match its public inputs", ErrBadProof)
+	}
+	return public, nil
+}
 
-	witness, err := frontend.NewWitness(assignment, field)
+// PublicInputsFromVector is the inverse of PublicInputs.Vector, e.g. for
+// public inputs read from a snarkjs public.json. The policy must be
+// registered, as its mode decides the layout.
+func PublicInputsFromVector(v []*big.Int) (PublicInputs, error) {
+	if len(v) < 2 {
+		return PublicInputs{}, fmt.Errorf("%w: %d public inputs", ErrBadProof, len(v))
+	}
+	if !v[0].IsInt64() || !v[1].IsInt64() {
+		return PublicInputs{}, fmt.Errorf("%w: policy id or version out of range", ErrBadProof)
+	}
+	policy, err := circuits.LookupPolicy(v[0].Int64())
 	if err != nil {
-		log.Fatalf("make witness failed: %v", err)
+		return PublicInputs{}, err
+	}
+	count := publicInputCount
+	if policy.Mode == circuits.ModeRateLimit {
+		count = rateLimitInputCount
+	}
+	if len(v) != count {
+		return PublicInputs{}, fmt.Errorf("%w: %d public inputs, circuit has %d",
+			ErrBadProof, len(v), count)
+	}
+	public := PublicInputs{
+		PolicyID:    v[0].Int64(),
+		Version:     v[1].Int64(),
+		Threshold:   v[3],
+		UpperBound:  v[4],
+		IssuersRoot: v[5],
+		Nullifier:   v[count-1],
 	}
+	if policy.Mode == circuits.ModeCommitment {
+		public.C = v[2]
+	} else {
+		public.CommitmentsRoot = v[2]
+	}
+	if policy.Mode == circuits.ModeRateLimit {
+		public.Epoch, public.MessageIndex, public.MessageLimit = v[6], v[7], v[8]
+	} else {
+		public.Scope = v[6]
+	}
+	return public, nil
+}
 
-	// 3) Load proof
-	fproof, err := os.Open("proof_age.bin")
+// Vector returns the public inputs as field elements in circuit order. The
+// layout is the one of the registered policy's mode, as in
+// PublicInputsFromVector: every input of that mode must be set, and the
+// fields of other modes must be nil rather than silently dropped.
+func (p PublicInputs) Vector() ([]*big.Int, error) {
+	policy, err := circuits.LookupPolicy(p.PolicyID)
 	if err != nil {
-		log.Fatalf("proof open failed: %v", err)
+		return nil, err
+	}
+	var v, other []*big.Int
+	switch policy.Mode {
+	case circuits.ModeCommitment:
+		v = []*big.Int{p.C, p.Threshold, p.UpperBound, p.IssuersRoot, p.Scope}
+		other = []*big.Int{p.CommitmentsRoot, p.Epoch, p.MessageIndex, p.MessageLimit}
+	case circuits.ModeMembership:
+		v = []*big.Int{p.CommitmentsRoot, p.Threshold, p.UpperBound, p.IssuersRoot, p.Scope}
+		other = []*big.Int{p.C, p.Epoch, p.MessageIndex, p.MessageLimit}
+	case circuits.ModeRateLimit:
+		v = []*big.Int{p.CommitmentsRoot, p.Threshold, p.UpperBound, p.IssuersRoot, p.Epoch, p.MessageIndex, p.MessageLimit}
+		other = []*big.Int{p.C, p.Scope}
+	default:
+		return nil, fmt.Errorf("policy %d: unknown mode %s", policy.ID, policy.Mode)
 	}
-	defer fproof.Close()
+	v = append(append([]*big.Int{big.NewInt(p.PolicyID), big.NewInt(p.Version)}, v...), p.Nullifier)
+	for i, x := range v {
+		if x == nil {
+			return nil, fmt.Errorf("policy %d (%s mode): public input %d is missing", policy.ID, policy.Mode, i)
+		}
+	}
+	for _, x := range other {
+		if x != nil {
+			return nil, fmt.Errorf("policy %d (%s mode): public inputs set a field of another mode", policy.ID, policy.Mode)
+		}
+	}
+	return v, nil
+}
 
-	proof := groth16.NewProof(ecc.BN254)
-	if _, err := proof.ReadFrom(fproof); err != nil {
-		log.Fatalf("proof parse failed: %v", err)
+// VerifyProof checks a proof bundle: it must have been made for vk, for a
+// registered policy version and the verifier's bounds, and the proof must
+// verify against its public inputs.
+func VerifyProof(b *bundle.Bundle, bounds Bounds, vk groth16.VerifyingKey) error {
+	fp, err := bundle.FingerprintOf(vk)
+	if err != nil {
+		return err
 	}
+	if fp != b.VKFingerprint {
+		return fmt.Errorf("%w: bundle is for verifying key %s, have %s", ErrBadProof, b.VKFingerprint, fp)
+	}
+	policy, err := circuits.LookupPolicy(b.PolicyID)
+	if err != nil {
+		return err
+	}
+	if policy.Version != b.Version {
+		return fmt.Errorf("%w: bundle is for policy %d v%d, registered version is v%d",
+			ErrBadProof, b.PolicyID, b.Version, policy.Version)
+	}
+	public, err := PublicInputsOf(b)
+	if err != nil {
+		return err
+	}
+	return VerifyPublicInputs(b.Proof, public, bounds, vk)
+}
 
-	// 4) Extract public witness
-	publicWitness, err := witness.Public()
+// VerifyPublicInputs checks a bare proof against its public inputs, which
+// must carry the verifier's bounds, and the verifying key. It writes
+// nothing; callers log the outcome as they see fit.
+func VerifyPublicInputs(
+	proof groth16.Proof,
+	public PublicInputs,
+	bounds Bounds,
+	vk groth16.VerifyingKey,
+) error {
+	if err := bounds.Check(public); err != nil {
+		return err
+	}
+	// 1) Build the public witness only (private fields stay unassigned)
+	assignment, err := publicAssignment(public)
 	if err != nil {
-		log.Fatalf("public input failed: %v", err)
+		return err
 	}
-	// The order of publicWitness must match the order of public inputs defined in the circuit
-	fmt.Println("Public inputs for verification:")
-	vec := publicWitness.Vector().(fr.Vector)
-	for i, v := range vec {
-		fmt.Printf("Public input %d: %s\n", i, v.String())
+	publicWitness, err := frontend.NewWitness(assignment, fr.Modulus(), frontend.PublicOnly())
+	if err != nil {
+		return fmt.Errorf("make public witness failed: %w", err)
 	}
 
-	// 5) Run Groth16 verification
+	// 2) Run Groth16 verification; the order of publicWitness is the order
+	//    of public inputs defined in the circuit
 	if err := groth16.Verify(proof, vk, publicWitness); err != nil {
-		log.Fatalf("Verification FAILED: %v", err)
+		return fmt.Errorf("%w: %v", ErrBadProof, err)
 	}
-
-	log.Println("Verification SUCCESS (off-chain) ✅")
+	return nil
 }
 
-// AssignmentCircuit is the witness constructor used on the user side.
-// It is responsible for converting all fields (string / number / bytes)
-// into field elements (big.Int) inside the circuit.
-func NewAssignmentCircuit(
-	policyID, version, threshold int64,
-	name, nation, address string,
-	age, identityID int64,
-	attrValue []byte, // fingerprint features (bytes)
-	did, C *big.Int,
-) (*circuits.Circuit, error) {
-
-	// 1. Helper: Keccak256(bytes) → *big.Int
-	toBigInt := func(data []byte) *big.Int {
-		h := sha3.NewLegacyKeccak256()
-		h.Write(data)
-		sum := h.Sum(nil)
-		return new(big.Int).SetBytes(sum)
-	}
-
-	// 2. Hash string fields
-	nameInt := toBigInt([]byte(name))
-	nationInt := toBigInt([]byte(nation))
-	addressInt := toBigInt([]byte(address))
-
-	// 3. Process numeric fields
-	ageBytes := big.NewInt(age).Bytes()
-	ageInt := big.NewInt(age) // Do NOT hash for age; it's a raw value used for comparison
-
-	idBytes := big.NewInt(identityID).Bytes()
-	identityInt := toBigInt(idBytes)
-
-	// 4. AttrValue (fingerprint) raw bytes → hash
-	attrInt := toBigInt(attrValue)
-
-	// 5. DID: hash the concatenation of original fields
-	//    Keccak256(name + nation + address + age + identityID + attrValue)
-	didHasher := sha3.NewLegacyKeccak256()
-	didHasher.Write([]byte(name))
-	didHasher.Write([]byte(nation))
-	didHasher.Write([]byte(address))
-	didHasher.Write(ageBytes)
-	didHasher.Write(idBytes)
-	didHasher.Write(attrValue)
-	didSum := didHasher.Sum(nil)
-	didInt := new(big.Int).SetBytes(didSum)
-	log.Printf("Constructed DID (decimal): %s\n", didInt.String())
-
-	if did.Cmp(didInt) != 0 {
-		log.Fatalf("did not match")
-	}
-
-	// 6. Construct the assignment
-	assign := &circuits.Circuit{
-		PolicyID:  big.NewInt(policyID),
-		Version:   big.NewInt(version),
-		C:         C,
-		Threshold: big.NewInt(threshold),
-
-		Name:       nameInt,
-		Age:        ageInt,
-		Nation:     nationInt,
-		Address:    addressInt,
-		IdentityID: identityInt,
-		AttrValue:  attrInt,
-		DID:        didInt,
-	}
-
-	return assign, nil
+// publicAssignment encodes the public inputs with the same rules as the
+// prover, into the circuit of the policy's mode.
+func publicAssignment(public PublicInputs) (frontend.Circuit, error) {
+	policy, err := circuits.LookupPolicy(public.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	policyID, err := encoding.Uint("policy_id", public.PolicyID)
+	if err != nil {
+		return nil, err
+	}
+	version, err := encoding.Uint("version", public.Version)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := encoding.Element("threshold", public.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	upperBound, err := encoding.Element("upper_bound", public.UpperBound)
+	if err != nil {
+		return nil, err
+	}
+	issuersRoot, err := encoding.Element("issuers_root", public.IssuersRoot)
+	if err != nil {
+		return nil, err
+	}
+	nullifier, err := encoding.Element("nullifier", public.Nullifier)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy.Mode == circuits.ModeRateLimit {
+		root, err := encoding.Element("commitments_root", public.CommitmentsRoot)
+		if err != nil {
+			return nil, err
+		}
+		epoch, err := encoding.Element("epoch", public.Epoch)
+		if err != nil {
+			return nil, err
+		}
+		index, err := encoding.Element("message_index", public.MessageIndex)
+		if err != nil {
+			return nil, err
+		}
+		limit, err := encoding.Element("message_limit", public.MessageLimit)
+		if err != nil {
+			return nil, err
+		}
+		return &circuits.RateLimitCircuit{
+			PolicyID:        policyID,
+			Version:         version,
+			CommitmentsRoot: root,
+			Threshold:       threshold,
+			UpperBound:      upperBound,
+			IssuersRoot:     issuersRoot,
+			Epoch:           epoch,
+			MessageIndex:    index,
+			MessageLimit:    limit,
+			Nullifier:       nullifier,
+		}, nil
+	}
+	scope, err := encoding.Element("scope", public.Scope)
+	if err != nil {
+		return nil, err
+	}
+	if policy.Mode == circuits.ModeMembership {
+		root, err := encoding.Element("commitments_root", public.CommitmentsRoot)
+		if err != nil {
+			return nil, err
+		}
+		return &circuits.MembershipCircuit{
+			PolicyID:        policyID,
+			Version:         version,
+			CommitmentsRoot: root,
+			Threshold:       threshold,
+			UpperBound:      upperBound,
+			IssuersRoot:     issuersRoot,
+			Scope:           scope,
+			Nullifier:       nullifier,
+		}, nil
+	}
+	C, err := encoding.Element("commitment", public.C)
+	if err != nil {
+		return nil, err
+	}
+	return &circuits.Circuit{
+		PolicyID:    policyID,
+		Version:     version,
+		C:           C,
+		Threshold:   threshold,
+		UpperBound:  upperBound,
+		IssuersRoot: issuersRoot,
+		Scope:       scope,
+		Nullifier:   nullifier,
+	}, nil
 }
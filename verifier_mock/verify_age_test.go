@@ -0,0 +1,94 @@
+package verify_age
+
+import (
+	"math/big"
+	"testing"
+)
+
+// publicInputs returns distinct public inputs in the layout of policyID.
+func publicInputs(policyID int64, rateLimit, membership bool) PublicInputs {
+	p := PublicInputs{
+		PolicyID:    policyID,
+		Version:     1,
+		Threshold:   big.NewInt(18),
+		UpperBound:  big.NewInt(0),
+		IssuersRoot: big.NewInt(101),
+		Nullifier:   big.NewInt(102),
+	}
+	if rateLimit || membership {
+		p.CommitmentsRoot = big.NewInt(103)
+	} else {
+		p.C = big.NewInt(104)
+	}
+	if rateLimit {
+		p.Epoch, p.MessageIndex, p.MessageLimit = big.NewInt(105), big.NewInt(2), big.NewInt(3)
+	} else {
+		p.Scope = big.NewInt(106)
+	}
+	return p
+}
+
+func TestVectorRoundTrip(t *testing.T) {
+	for _, p := range []PublicInputs{
+		publicInputs(1, false, false),
+		publicInputs(11, false, true),
+		publicInputs(21, true, false),
+	} {
+		v, err := p.Vector()
+		if err != nil {
+			t.Fatalf("policy %d: %v", p.PolicyID, err)
+		}
+		got, err := PublicInputsFromVector(v)
+		if err != nil {
+			t.Fatalf("policy %d: %v", p.PolicyID, err)
+		}
+		again, err := got.Vector()
+		if err != nil {
+			t.Fatalf("policy %d: %v", p.PolicyID, err)
+		}
+		if len(again) != len(v) {
+			t.Fatalf("policy %d: %d inputs, then %d", p.PolicyID, len(v), len(again))
+		}
+		for i := range v {
+			if v[i].Cmp(again[i]) != 0 {
+				t.Fatalf("policy %d: input %d does not round-trip", p.PolicyID, i)
+			}
+		}
+	}
+}
+
+// TestVectorLayoutFromPolicy checks that the layout follows the policy mode,
+// not which fields happen to be set.
+func TestVectorLayoutFromPolicy(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		p    PublicInputs
+	}{
+		{"rate-limit fields on a commitment policy", func() PublicInputs {
+			p := publicInputs(1, false, false)
+			p.MessageLimit = big.NewInt(3)
+			return p
+		}()},
+		{"C on a membership policy", func() PublicInputs {
+			p := publicInputs(11, false, true)
+			p.C = big.NewInt(104)
+			return p
+		}()},
+		{"rate-limit policy without message limit", func() PublicInputs {
+			p := publicInputs(21, true, false)
+			p.MessageLimit = nil
+			return p
+		}()},
+		{"rate-limit policy with a scope", func() PublicInputs {
+			p := publicInputs(21, true, false)
+			p.Scope = big.NewInt(106)
+			return p
+		}()},
+		{"commitment-mode inputs for a rate-limit policy", publicInputs(21, false, false)},
+		{"unregistered policy", publicInputs(99, false, false)},
+	} {
+		if v, err := tc.p.Vector(); err == nil {
+			t.Errorf("%s: got %d inputs, want an error", tc.name, len(v))
+		}
+	}
+}
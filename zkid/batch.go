@@ -0,0 +1,80 @@
+package zkid
+
+import (
+	"context"
+	"sync"
+)
+
+// BatchOptions configures Prover.ProveBatch.
+type BatchOptions struct {
+	// Workers is the number of proofs generated concurrently. Each Groth16
+	// proof already uses several cores and allocates memory proportional to
+	// the circuit size, so Workers is also the memory bound of the batch.
+	// Defaults to 2.
+	Workers int
+}
+
+// BatchItem is one identity to prove. ID is echoed in its result.
+type BatchItem struct {
+	ID     string
+	Inputs Inputs
+}
+
+// BatchResult is the outcome of one BatchItem: either Proof or Err is set.
+type BatchResult struct {
+	ID    string
+	Proof *Proof
+	Err   error
+}
+
+// ProveBatch proves the items received on items with a bounded worker pool
+// sharing the Prover's key and constraint system. Results are streamed in
+// completion order as soon as each proof is done.
+//
+// Items are pulled only when a worker is free and results are sent unbuffered,
+// so neither a fast producer nor a slow consumer makes the batch hold more
+// than Workers witnesses at a time. The results channel is closed once items
+// is closed and drained, or once ctx is cancelled; items not yet started are
+// then left unread. The caller must drain the results channel.
+func (p *Prover) ProveBatch(ctx context.Context, items <-chan BatchItem, opts BatchOptions) <-chan BatchResult {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = 2
+	}
+
+	results := make(chan BatchResult)
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for {
+				var (
+					item BatchItem
+					ok   bool
+				)
+				select {
+				case <-ctx.Done():
+					return
+				case item, ok = <-items:
+					if !ok {
+						return
+					}
+				}
+
+				proof, err := p.Prove(ctx, item.Inputs)
+				select {
+				case results <- BatchResult{ID: item.ID, Proof: proof, Err: err}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+	return results
+}
@@ -0,0 +1,185 @@
+package zkid
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/issuer"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+)
+
+// failingProver returns a Prover without keys; it only serves items whose
+// inputs are rejected before proving.
+func failingProver(t *testing.T) *Prover {
+	t.Helper()
+	policy, err := circuits.LookupPolicy(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Prover{policy: policy}
+}
+
+// feed sends n items with invalid inputs on the returned channel until ctx
+// is done, counting the items taken by the batch in sent.
+func feed(ctx context.Context, n int, sent *atomic.Int64) <-chan BatchItem {
+	items := make(chan BatchItem)
+	go func() {
+		defer close(items)
+		for i := 0; i < n; i++ {
+			select {
+			case items <- BatchItem{ID: fmt.Sprint(i)}:
+				sent.Add(1)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return items
+}
+
+// drain reads results until the channel is closed or the timeout expires.
+func drain(t *testing.T, results <-chan BatchResult, timeout time.Duration) []BatchResult {
+	t.Helper()
+	var out []BatchResult
+	deadline := time.After(timeout)
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				return out
+			}
+			out = append(out, r)
+		case <-deadline:
+			t.Fatal("results channel not closed")
+		}
+	}
+}
+
+func TestProveBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var sent atomic.Int64
+	results := failingProver(t).ProveBatch(ctx, feed(ctx, 1000, &sent), BatchOptions{Workers: 3})
+
+	for i := 0; i < 5; i++ {
+		if r := <-results; r.Err == nil {
+			t.Fatalf("item %s: invalid inputs proved", r.ID)
+		}
+	}
+	cancel()
+	// a closed channel means every worker has returned
+	rest := drain(t, results, 10*time.Second)
+	if n := sent.Load(); n >= 1000 {
+		t.Fatalf("all %d items were pulled after cancellation", n)
+	}
+	if got := int64(5 + len(rest)); got > sent.Load() {
+		t.Fatalf("%d results for %d items", got, sent.Load())
+	}
+}
+
+// TestProveBatchBounded checks that a batch whose results are not read
+// pulls no more items than it has workers.
+func TestProveBatchBounded(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var sent atomic.Int64
+	const workers = 2
+	results := failingProver(t).ProveBatch(ctx, feed(ctx, 100, &sent), BatchOptions{Workers: workers})
+
+	time.Sleep(200 * time.Millisecond)
+	if n := sent.Load(); n > workers {
+		t.Fatalf("%d items pulled with %d workers and no reader", n, workers)
+	}
+	cancel()
+	drain(t, results, 10*time.Second)
+}
+
+// TestProveBatchItemError checks that a failing item yields its own error
+// result and the other items are still proven.
+func TestProveBatchItemError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs a Groth16 setup")
+	}
+	ctx := context.Background()
+	setup, err := NewSetup(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys, err := setup.Run(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	prover := NewProverFromKeys(keys)
+
+	key, err := issuer.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuers, err := issuer.NewSet(&key.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := ProofOptions{Issuers: issuers, Threshold: big.NewInt(18)}
+	inputs := func(age int64) Inputs {
+		t.Helper()
+		secret, err := proof_age.GenerateSecret()
+		if err != nil {
+			t.Fatal(err)
+		}
+		salt, err := proof_age.GenerateSalt()
+		if err != nil {
+			t.Fatal(err)
+		}
+		attr := []byte{0xf1, 0x9e, 0x42}
+		did, err := proof_age.ComputeLocalDID(proof_age.DIDv2, "Alice", "FR", "1 rue de la Paix", age, 4242, attr, secret)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cred, err := proof_age.NewCredential(keys.Policy, "Alice", "FR", "1 rue de la Paix", age, 4242, attr, secret, did, salt)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := cred.Sign(key); err != nil {
+			t.Fatal(err)
+		}
+		in := CredentialInputs(cred, opts)
+		in.Secret = secret
+		return in
+	}
+
+	items := make(chan BatchItem, 3)
+	items <- BatchItem{ID: "adult", Inputs: inputs(30)}
+	items <- BatchItem{ID: "minor", Inputs: inputs(12)}
+	items <- BatchItem{ID: "senior", Inputs: inputs(70)}
+	close(items)
+
+	got := map[string]BatchResult{}
+	for _, r := range drain(t, prover.ProveBatch(ctx, items, BatchOptions{Workers: 2}), 5*time.Minute) {
+		got[r.ID] = r
+	}
+	if len(got) != 3 {
+		t.Fatalf("%d results for 3 items", len(got))
+	}
+	if !errors.Is(got["minor"].Err, ErrPredicateUnsatisfied) {
+		t.Fatalf("minor: got %v, want ErrPredicateUnsatisfied", got["minor"].Err)
+	}
+	for _, id := range []string{"adult", "senior"} {
+		if got[id].Err != nil || got[id].Proof == nil {
+			t.Fatalf("%s: %v", id, got[id].Err)
+		}
+	}
+
+	verifier, err := NewVerifier(1, keys.VK, Bounds{Threshold: big.NewInt(18)}, issuers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier.Verify(ctx, got["adult"].Proof); err != nil {
+		t.Fatalf("batch proof does not verify: %v", err)
+	}
+}
@@ -0,0 +1,155 @@
+package zkid
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+	"github.com/kanthub/zkid-zkp/store"
+)
+
+// Prover generates proofs for one policy. It is meant to be long-lived: the
+// constraint system and the ProvingKey are loaded once and kept warm, and
+// Prove is safe for concurrent use by multiple goroutines. Proofs are
+// returned to the caller; nothing is written to disk.
+type Prover struct {
+	policy circuits.Policy
+	cs     constraint.ConstraintSystem
+	pk     groth16.ProvingKey
+}
+
+// NewProver compiles the policy circuit once and binds it to pk.
+func NewProver(ctx context.Context, policyID int64, pk groth16.ProvingKey) (*Prover, error) {
+	policy, err := circuits.LookupPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cs, err := circuits.Compile(policy)
+	if err != nil {
+		return nil, err
+	}
+	return &Prover{policy: policy, cs: cs, pk: pk}, nil
+}
+
+// NewProverFromStore loads the compiled circuit and the ProvingKey of the
+// registered version of policyID from st. The circuit is not recompiled; its
+// hash is checked against the manifest recorded with the key.
+func NewProverFromStore(ctx context.Context, st *store.Store, policyID int64) (*Prover, error) {
+	policy, err := circuits.LookupPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cs, err := st.LoadConstraintSystem(policy.ID, policy.Version)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	pk, err := st.LoadProvingKey(policy.ID, policy.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &Prover{policy: policy, cs: cs, pk: pk}, nil
+}
+
+// NewProverFromKeys reuses the constraint system produced by Setup.Run.
+func NewProverFromKeys(keys *Keys) *Prover {
+	return &Prover{policy: keys.Policy, cs: keys.CS, pk: keys.PK}
+}
+
+// Policy returns the policy the Prover proves.
+func (p *Prover) Policy() circuits.Policy {
+	return p.policy
+}
+
+// Prove builds the witness, checks DID, issuer signature and predicate
+// off-circuit and runs the Groth16 prover. The context is checked before
+// proving; a running prover cannot be interrupted.
+func (p *Prover) Prove(ctx context.Context, in Inputs) (*Proof, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	assignment, err := proof_age.NewAssignmentCircuit(
+		p.policy.ID, p.policy.Version,
+		in.Threshold, in.UpperBound,
+		in.Name, in.Nation, in.Address,
+		in.Age, in.IdentityID,
+		in.AttrValue, in.Secret,
+		in.DID, in.Salt, in.C,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build assignment: %w", err)
+	}
+	if err := proof_age.SetIssuer(assignment, in.Issuers, in.Issuer, in.Signature); err != nil {
+		return nil, err
+	}
+	if err := proof_age.SetScope(assignment, in.Scope); err != nil {
+		return nil, err
+	}
+	if err := proof_age.CheckPredicate(p.policy, assignment); err != nil {
+		return nil, err
+	}
+	circuit, err := proof_age.PolicyAssignment(p.policy, assignment, in.Commitments, in.Message)
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	proof, _, err := proof_age.Prove(p.cs, p.pk, circuit)
+	if err != nil {
+		return nil, err
+	}
+
+	public := PublicInputs{
+		PolicyID:    p.policy.ID,
+		Version:     p.policy.Version,
+		C:           assignment.C.(*big.Int),
+		Threshold:   assignment.Threshold.(*big.Int),
+		UpperBound:  assignment.UpperBound.(*big.Int),
+		IssuersRoot: assignment.IssuersRoot.(*big.Int),
+		Scope:       assignment.Scope.(*big.Int),
+		Nullifier:   assignment.Nullifier.(*big.Int),
+	}
+	switch c := circuit.(type) {
+	case *circuits.MembershipCircuit:
+		public.C = nil
+		public.CommitmentsRoot = c.CommitmentsRoot.(*big.Int)
+	case *circuits.RateLimitCircuit:
+		public.C = nil
+		public.CommitmentsRoot = c.CommitmentsRoot.(*big.Int)
+		public.Scope = nil
+		public.Epoch = c.Epoch.(*big.Int)
+		public.MessageIndex = c.MessageIndex.(*big.Int)
+		public.MessageLimit = c.MessageLimit.(*big.Int)
+		public.Nullifier = c.Nullifier.(*big.Int)
+	}
+	return &Proof{Proof: proof, Public: public}, nil
+}
+
+// ProveCredential validates cred, checks that it was issued for the Prover's
+// policy and proves it with opts: its issuer hidden in opts.Issuers and, in
+// membership and rate-limit modes, its commitment in opts.Commitments.
+// opts.Secret is required for DIDv2 credentials.
+func (p *Prover) ProveCredential(ctx context.Context, cred *Credential, opts ProofOptions) (*Proof, error) {
+	if err := cred.Validate(); err != nil {
+		return nil, err
+	}
+	if cred.PolicyID != p.policy.ID || cred.Version != p.policy.Version {
+		return nil, fmt.Errorf("credential is for policy %d v%d, prover for policy %d v%d",
+			cred.PolicyID, cred.Version, p.policy.ID, p.policy.Version)
+	}
+	return p.Prove(ctx, CredentialInputs(cred, opts))
+}
@@ -0,0 +1,51 @@
+package zkid
+
+import (
+	"context"
+
+	"github.com/consensys/gnark/backend/groth16"
+	"github.com/consensys/gnark/constraint"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	setup_keys "github.com/kanthub/zkid-zkp/keys"
+)
+
+// Keys is the output of a Groth16 setup for one policy.
+type Keys struct {
+	Policy circuits.Policy
+	CS     constraint.ConstraintSystem
+	PK     groth16.ProvingKey
+	VK     groth16.VerifyingKey
+}
+
+// Setup generates keys for one policy.
+type Setup struct {
+	policy circuits.Policy
+}
+
+// NewSetup returns a Setup for a registered policy.
+func NewSetup(policyID int64) (*Setup, error) {
+	policy, err := circuits.LookupPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	return &Setup{policy: policy}, nil
+}
+
+// Policy returns the policy the keys are generated for.
+func (s *Setup) Policy() circuits.Policy {
+	return s.policy
+}
+
+// Run compiles the circuit and runs the Groth16 setup in memory.
+// The caller decides where the keys are stored.
+func (s *Setup) Run(ctx context.Context) (*Keys, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	cs, pk, vk, err := setup_keys.Setup(s.policy)
+	if err != nil {
+		return nil, err
+	}
+	return &Keys{Policy: s.policy, CS: cs, PK: pk, VK: vk}, nil
+}
@@ -0,0 +1,207 @@
+package zkid
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/encoding"
+	"github.com/kanthub/zkid-zkp/issuer"
+	"github.com/kanthub/zkid-zkp/nullifier"
+	verify_age "github.com/kanthub/zkid-zkp/verifier_mock"
+)
+
+// Verifier checks proofs of one policy against its verifying key and its
+// bounds, and accepts only commitments signed by an issuer of one of its
+// trusted issuer sets. Which issuer signed stays hidden.
+type Verifier struct {
+	policy circuits.Policy
+	vk     groth16.VerifyingKey
+	bounds Bounds
+	roots  []*big.Int
+	// accepted commitment tree roots, membership and rate-limit modes only
+	commitmentRoots []*big.Int
+	// nullifier scope and the store of spent nullifiers, nil if unused
+	scope      *big.Int
+	nullifiers nullifier.Store
+	// rate limiter, rate-limit-mode policies only
+	limiter *nullifier.RateLimiter
+}
+
+// NewVerifier returns a Verifier for a registered policy that requires the
+// public bounds and trusts the given issuer sets. bounds is what the relying
+// party asks for, e.g. Threshold 18 for an adult gate; proofs against other
+// bounds fail with ErrBounds. Several sets can be trusted at once, e.g. the
+// previous and the current one while holders move to a rotated set. A
+// Verifier without sets rejects every proof.
+func NewVerifier(policyID int64, vk groth16.VerifyingKey, bounds Bounds, issuers ...*issuer.Set) (*Verifier, error) {
+	policy, err := circuits.LookupPolicy(policyID)
+	if err != nil {
+		return nil, err
+	}
+	threshold, err := encoding.Element("threshold", bounds.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	upperBound := big.NewInt(0)
+	if bounds.UpperBound != nil {
+		if upperBound, err = encoding.Element("upper_bound", bounds.UpperBound); err != nil {
+			return nil, err
+		}
+	}
+	if policy.Predicate.Operator != circuits.OpInRange && upperBound.Sign() != 0 {
+		return nil, fmt.Errorf("policy %d (%s) takes no upper bound", policy.ID, policy.Predicate)
+	}
+	v := &Verifier{policy: policy, vk: vk, bounds: Bounds{Threshold: threshold, UpperBound: upperBound}}
+	for _, s := range issuers {
+		v.roots = append(v.roots, s.Root())
+	}
+	return v, nil
+}
+
+// AcceptCommitmentRoots adds roots of the issuer's commitment tree that
+// membership- and rate-limit-mode proofs may be made against. The root changes with every
+// issued credential, so a verifier typically accepts the recent ones; a root
+// of a tree the holder built alone would identify them.
+func (v *Verifier) AcceptCommitmentRoots(roots ...*big.Int) {
+	v.commitmentRoots = append(v.commitmentRoots, roots...)
+}
+
+// UseNullifiers makes the Verifier accept only proofs for scope and at most
+// one proof per holder in it: after a proof verifies, its nullifier is added
+// to store, and a proof whose nullifier is already there fails with
+// ErrNullifierUsed. Verification then consumes the nullifier; verify each
+// claim once. scope must not be 0, which disables nullifiers in the circuit.
+func (v *Verifier) UseNullifiers(scope *big.Int, store nullifier.Store) error {
+	scope, err := encoding.Element("scope", scope)
+	if err != nil {
+		return err
+	}
+	if scope.Sign() == 0 {
+		return fmt.Errorf("nullifier scope must not be 0")
+	}
+	if v.policy.Mode == circuits.ModeRateLimit {
+		return fmt.Errorf("policy %d is rate limited, use UseRateLimit", v.policy.ID)
+	}
+	v.scope, v.nullifiers = scope, store
+	return nil
+}
+
+// UseRateLimit makes the Verifier of a rate-limit-mode policy accept only
+// proofs for the current (or previous) epoch of limiter and its message
+// limit, and at most that many proofs per holder and epoch: as with
+// UseNullifiers, a verified proof spends its nullifier and a repeated one
+// fails with ErrNullifierUsed. Without a limiter, proofs of any epoch and
+// limit verify and nothing is recorded.
+func (v *Verifier) UseRateLimit(limiter *nullifier.RateLimiter) error {
+	if v.policy.Mode != circuits.ModeRateLimit {
+		return fmt.Errorf("policy %d is in %s mode, not rate limited", v.policy.ID, v.policy.Mode)
+	}
+	v.limiter = limiter
+	return nil
+}
+
+// contains reports whether x is one of roots.
+func contains(roots []*big.Int, x *big.Int) bool {
+	if x == nil {
+		return false
+	}
+	for _, r := range roots {
+		if r.Cmp(x) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPublic checks the bounds, the issuer set root, in membership and
+// rate-limit modes the commitment tree root, with nullifiers the scope and with a rate limiter the epoch and
+// limit of public against the accepted ones.
+func (v *Verifier) checkPublic(public PublicInputs) error {
+	if err := v.bounds.Check(public); err != nil {
+		return err
+	}
+	if !contains(v.roots, public.IssuersRoot) {
+		return ErrUntrustedIssuer
+	}
+	if v.policy.Mode != circuits.ModeCommitment && !contains(v.commitmentRoots, public.CommitmentsRoot) {
+		return ErrUnknownCommitmentRoot
+	}
+	if v.nullifiers != nil && (public.Scope == nil || public.Scope.Cmp(v.scope) != 0) {
+		return fmt.Errorf("%w: proof is for scope %v, verifier expects %s", ErrBadProof, public.Scope, v.scope)
+	}
+	if v.limiter != nil {
+		return v.limiter.Check(time.Now(), public.Epoch, public.MessageIndex, public.MessageLimit)
+	}
+	return nil
+}
+
+// spend records the nullifier of a verified proof.
+func (v *Verifier) spend(ctx context.Context, public PublicInputs) error {
+	if v.limiter != nil {
+		return v.limiter.Add(ctx, public.Epoch, public.Nullifier)
+	}
+	if v.nullifiers == nil {
+		return nil
+	}
+	return v.nullifiers.Add(ctx, v.scope, public.Nullifier)
+}
+
+// Verify returns nil if the proof is valid for its public inputs, these are
+// the Verifier's bounds, its issuer set (and commitment tree) roots are
+// accepted and, with UseNullifiers, its nullifier is unused. It returns an
+// error wrapping ErrBadProof for an invalid proof, ErrBounds for other
+// bounds, ErrUntrustedIssuer for an unknown issuer set,
+// ErrUnknownCommitmentRoot for an unknown commitment tree, ErrNullifierUsed
+// for a repeated claim and, in rate-limit mode, ErrEpoch for a stale epoch
+// and ErrRateLimited for another message limit.
+func (v *Verifier) Verify(ctx context.Context, proof *Proof) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if proof == nil || proof.Proof == nil {
+		return fmt.Errorf("%w: missing proof", ErrBadProof)
+	}
+	if proof.Public.PolicyID != v.policy.ID || proof.Public.Version != v.policy.Version {
+		return fmt.Errorf("%w: proof is for policy %d v%d, verifier expects %d v%d",
+			ErrBadProof, proof.Public.PolicyID, proof.Public.Version, v.policy.ID, v.policy.Version)
+	}
+	if err := v.checkPublic(proof.Public); err != nil {
+		return err
+	}
+	if err := verify_age.VerifyPublicInputs(proof.Proof, proof.Public, v.bounds, v.vk); err != nil {
+		return err
+	}
+	return v.spend(ctx, proof.Public)
+}
+
+// VerifyBundle checks a proof bundle: it must be for the Verifier's policy,
+// bounds and verifying key, made against accepted roots, and its proof must
+// verify.
+func (v *Verifier) VerifyBundle(ctx context.Context, b *Bundle) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if b == nil || b.Proof == nil {
+		return fmt.Errorf("%w: missing proof", ErrBadProof)
+	}
+	if b.PolicyID != v.policy.ID || b.Version != v.policy.Version {
+		return fmt.Errorf("%w: bundle is for policy %d v%d, verifier expects %d v%d",
+			ErrBadProof, b.PolicyID, b.Version, v.policy.ID, v.policy.Version)
+	}
+	public, err := verify_age.PublicInputsOf(b)
+	if err != nil {
+		return err
+	}
+	if err := v.checkPublic(public); err != nil {
+		return err
+	}
+	if err := verify_age.VerifyProof(b, v.bounds, v.vk); err != nil {
+		return err
+	}
+	return v.spend(ctx, public)
+}
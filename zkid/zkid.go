@@ -0,0 +1,135 @@
+// Package zkid is the library entry point of zkID: it wraps circuit setup,
+// proving and verification behind Setup, Prover and Verifier types whose
+// methods return errors instead of terminating the process.
+package zkid
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/consensys/gnark/backend/groth16"
+
+	"github.com/kanthub/zkid-zkp/bundle"
+	"github.com/kanthub/zkid-zkp/circuits"
+	"github.com/kanthub/zkid-zkp/issuer"
+	"github.com/kanthub/zkid-zkp/merkle"
+	"github.com/kanthub/zkid-zkp/nullifier"
+	proof_age "github.com/kanthub/zkid-zkp/proof"
+	verify_age "github.com/kanthub/zkid-zkp/verifier_mock"
+)
+
+// Errors returned by this package. Use errors.Is to test for them.
+var (
+	ErrUnknownPolicy        = circuits.ErrUnknownPolicy
+	ErrDIDMismatch          = proof_age.ErrDIDMismatch
+	ErrPredicateUnsatisfied = proof_age.ErrPredicateUnsatisfied
+	ErrBadProof             = verify_age.ErrBadProof
+	ErrBounds               = verify_age.ErrBounds
+	ErrUnsigned             = proof_age.ErrUnsigned
+	ErrBadSignature         = issuer.ErrBadSignature
+	ErrNotInSet             = issuer.ErrNotInSet
+
+	// ErrUntrustedIssuer is returned by a Verifier for a proof made against
+	// an issuer set root it does not trust.
+	ErrUntrustedIssuer = errors.New("untrusted issuer")
+
+	// ErrUnknownCommitmentRoot is returned by a Verifier for a membership-
+	// or rate-limit-mode proof made against a commitment tree root it has
+	// not accepted.
+	ErrUnknownCommitmentRoot = errors.New("unknown commitment tree root")
+
+	ErrNullifierUsed = nullifier.ErrUsed
+	ErrRateLimited   = nullifier.ErrRateLimited
+	ErrEpoch         = nullifier.ErrEpoch
+)
+
+// PublicInputs are the public inputs of a proof, in circuit order.
+type PublicInputs = verify_age.PublicInputs
+
+// Bounds are the public Threshold and UpperBound a Verifier requires.
+type Bounds = verify_age.Bounds
+
+// Inputs are everything the holder supplies for one proof. The policy is
+// fixed by the Prover.
+type Inputs struct {
+	Threshold  *big.Int
+	UpperBound *big.Int // only used by range predicates, 0 otherwise
+
+	Name, Nation, Address string
+	Age, IdentityID       int64
+	AttrValue             []byte
+	Secret                *big.Int // holder secret, nil for DIDv1
+	DID, Salt, C          *big.Int
+
+	Issuer    *issuer.PublicKey // issuer that signed C
+	Signature []byte            // issuer signature on C
+	Issuers   *issuer.Set       // trusted set holding Issuer; its root is public
+
+	// Issued commitments holding C, membership and rate-limit modes only;
+	// its root is public instead of C
+	Commitments *merkle.Tree
+
+	// Verifier-chosen scope of the nullifier, nil or 0 for none
+	Scope *big.Int
+
+	// Epoch and message index, rate-limit-mode policies only
+	Message *Message
+}
+
+// Message selects the epoch and message index of a rate-limit proof.
+type Message = proof_age.Message
+
+// Credential is an issued credential file, see proof_age.CredentialSchema.
+type Credential = proof_age.Credential
+
+// ProofOptions are the inputs of a credential proof besides the credential:
+// the trusted issuer set, the holder secret, the public bounds and, by
+// policy mode, the commitment tree, scope or message.
+type ProofOptions = proof_age.ProofOptions
+
+// CredentialInputs returns the Inputs proving cred with opts.
+func CredentialInputs(cred *Credential, opts ProofOptions) Inputs {
+	upperBound := opts.UpperBound
+	if upperBound == nil {
+		upperBound = big.NewInt(0)
+	}
+	return Inputs{
+		Threshold:  opts.Threshold,
+		UpperBound: upperBound,
+		Name:       cred.Name,
+		Nation:     cred.Nation,
+		Address:    cred.Address,
+		Age:        cred.Age,
+		IdentityID: cred.IdentityID,
+		AttrValue:  cred.AttrValue,
+		Secret:     opts.Secret,
+		DID:        cred.DID,
+		Salt:       cred.Salt,
+		C:          cred.C,
+		Issuer:     cred.IssuerKey,
+		Signature:  cred.Signature,
+		Issuers:    opts.Issuers,
+
+		Commitments: opts.Commitments,
+		Scope:       opts.Scope,
+		Message:     opts.Message,
+	}
+}
+
+// Proof is a Groth16 proof together with the public inputs it was made for.
+type Proof struct {
+	Proof  groth16.Proof
+	Public PublicInputs
+}
+
+// Bundle is a self-describing proof, see package bundle.
+type Bundle = bundle.Bundle
+
+// Bundle wraps the proof into a bundle for the verifying key vk.
+func (p *Proof) Bundle(vk groth16.VerifyingKey) (*Bundle, error) {
+	public, err := p.Public.Vector()
+	if err != nil {
+		return nil, err
+	}
+	return bundle.New(p.Public.PolicyID, p.Public.Version, public, vk, p.Proof)
+}